@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"neo4j_delivery/internal/auth"
+	"neo4j_delivery/internal/export"
+	"neo4j_delivery/internal/importer"
+	"neo4j_delivery/internal/models"
+	"neo4j_delivery/internal/repositories"
+	"neo4j_delivery/internal/services"
+)
+
+// Comandos de línea de órdenes. Se ejecutan en lugar del servidor y no recargan scripts/data.cypher
+var commands = map[string]func(service *services.DeliveryService, args []string) error{
+	"export":   runExport,
+	"import":   runImport,
+	"validate": runValidate,
+	"snapshot": runSnapshot,
+	"restore":  runRestore,
+}
+
+func runCommand(service *services.DeliveryService, args []string) {
+	command, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", args[0])
+		os.Exit(2)
+	}
+	if err := command(service, args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// export -format dot|graphml|csv [-part nodes|edges] [-out archivo]
+func runExport(service *services.DeliveryService, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	format := flags.String("format", export.FormatDOT, "dot, graphml or csv")
+	part := flags.String("part", export.PartNodes, "nodes or edges (csv only)")
+	out := flags.String("out", "", "output file, stdout if empty")
+	flags.Parse(args)
+
+	data, err := service.GetGraphData(cliContext())
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	return export.Write(w, *format, *part, data)
+}
+
+// import (-json archivo | -zones zonas.csv -connections tramos.csv) [-dry-run] [-strict] [-batch n]
+func runImport(service *services.DeliveryService, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	jsonFile := flags.String("json", "", "JSON file with zones and connections")
+	zonesFile := flags.String("zones", "", "zones CSV file")
+	connectionsFile := flags.String("connections", "", "connections CSV file")
+	dryRun := flags.Bool("dry-run", false, "validate and report without writing")
+	strict := flags.Bool("strict", false, "also reject imports that add integrity warnings")
+	batch := flags.Int("batch", repositories.DefaultBatchSize, "rows per transaction")
+	flags.Parse(args)
+
+	data := models.ImportData{}
+	if *jsonFile != "" {
+		file, err := os.Open(*jsonFile)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		if data, err = importer.ParseJSON(file); err != nil {
+			return err
+		}
+	}
+	if *zonesFile != "" {
+		file, err := os.Open(*zonesFile)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		if data.Zones, err = importer.ParseZonesCSV(file); err != nil {
+			return err
+		}
+	}
+	if *connectionsFile != "" {
+		file, err := os.Open(*connectionsFile)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		if data.Connections, err = importer.ParseConnectionsCSV(file); err != nil {
+			return err
+		}
+	}
+
+	options := models.ImportOptions{DryRun: *dryRun, Strict: *strict, BatchSize: *batch}
+	report, err := service.ImportNetwork(cliContext(), data, options)
+	if err != nil {
+		return err
+	}
+	printJSON(report)
+	if len(report.Errors) > 0 {
+		return fmt.Errorf("import rejected: %d errors", len(report.Errors))
+	}
+	return nil
+}
+
+// validate [-strict]: termina con error si la red tiene errores de integridad (o advertencias con -strict)
+func runValidate(service *services.DeliveryService, args []string) error {
+	flags := flag.NewFlagSet("validate", flag.ExitOnError)
+	strict := flags.Bool("strict", false, "also fail on warnings")
+	flags.Parse(args)
+
+	report, err := service.ValidateNetwork(cliContext())
+	if err != nil {
+		return err
+	}
+	printJSON(report)
+	if !report.Valid || (*strict && report.Warnings > 0) {
+		return fmt.Errorf("network validation failed: %d errors, %d warnings", report.Errors, report.Warnings)
+	}
+	return nil
+}
+
+// snapshot [-description texto]: guarda la red actual como una nueva versión
+func runSnapshot(service *services.DeliveryService, args []string) error {
+	flags := flag.NewFlagSet("snapshot", flag.ExitOnError)
+	description := flags.String("description", "", "description of the snapshot")
+	flags.Parse(args)
+
+	info, err := service.CreateSnapshot(cliContext(), *description)
+	if err != nil {
+		return err
+	}
+	printJSON(info)
+	return nil
+}
+
+// restore -version n: devuelve la red a esa versión, guardando antes un respaldo
+func runRestore(service *services.DeliveryService, args []string) error {
+	flags := flag.NewFlagSet("restore", flag.ExitOnError)
+	version := flags.Int("version", 0, "snapshot version to restore")
+	flags.Parse(args)
+	if *version <= 0 {
+		return fmt.Errorf("restore needs -version")
+	}
+
+	result, err := service.RestoreSnapshot(cliContext(), *version)
+	if err != nil {
+		return err
+	}
+	printJSON(result)
+	return nil
+}
+
+func printJSON(value interface{}) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(value)
+}
+
+// Los comandos corren con acceso local, se auditan como el usuario del sistema operativo
+func cliContext() context.Context {
+	user := os.Getenv("USER")
+	if user == "" {
+		user = "unknown"
+	}
+	return auth.WithPrincipal(context.Background(), auth.Principal{Subject: "cli:" + user, Role: auth.RoleAdmin, Method: auth.MethodCLI})
+}
@@ -4,17 +4,31 @@ import (
 	_ "bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/rs/cors"
-	"log"
+	"log/slog"
+	"neo4j_delivery/internal/auth"
 	"neo4j_delivery/internal/config"
 	"neo4j_delivery/internal/database"
+	"neo4j_delivery/internal/events"
+	"neo4j_delivery/internal/export"
+	"neo4j_delivery/internal/health"
+	"neo4j_delivery/internal/importer"
+	"neo4j_delivery/internal/logging"
+	"neo4j_delivery/internal/metrics"
+	"neo4j_delivery/internal/models"
+	"neo4j_delivery/internal/openapi"
 	"neo4j_delivery/internal/repositories"
 	"neo4j_delivery/internal/services"
+	"neo4j_delivery/internal/tracing"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 )
@@ -22,129 +36,959 @@ import (
 func main() {
 	// Configuración
 	cfg := config.LoadConfig()
+	slog.SetDefault(logging.New(os.Stderr, cfg.LogLevel, cfg.LogFormat))
 
-	db, err := database.NewNeo4jDatabase(
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		slog.Error("could not set up tracing", "err", err)
+		os.Exit(1)
+	}
+	// Envía los spans pendientes al salir, tanto del servidor como de un comando de la CLI
+	defer shutdownTracing(context.Background())
+
+	// Neo4j puede tardar en arrancar más que el servidor, por eso se reintenta antes de rendirse
+	db, err := database.ConnectWithRetry(
 		cfg.Neo4jURI,
 		cfg.Neo4jUser,
 		cfg.Neo4jPassword,
+		cfg.Neo4jConnectAttempts,
+		time.Duration(cfg.Neo4jConnectBackoff)*time.Second,
 	)
 	if err != nil {
-		log.Fatal(err)
+		slog.Error("could not connect to Neo4j", "err", err)
+		os.Exit(1)
 	}
 
-	service := services.DeliveryService{repositories.NewZoneRepository(db.Driver),
-		repositories.NewRouteRepository(db.Driver)}
+	service := services.DeliveryService{
+		ZoneRepo:     repositories.NewZoneRepository(db.Driver),
+		RouteRepo:    repositories.NewRouteRepository(db.Driver),
+		FleetRepo:    repositories.NewFleetRepository(db.Driver),
+		AuditRepo:    repositories.NewAuditRepository(db.Driver),
+		SnapshotRepo: repositories.NewSnapshotRepository(db.Driver),
+		Events:       events.NewBroker(),
+
+		MaxSpeedKmh:   float64(cfg.MaxSpeedKmh),
+		GraphCacheTTL: time.Duration(cfg.GraphCacheTTL) * time.Second,
+		LocateMaxKm:   float64(cfg.LocateMaxKm),
+	}
 
 	defer db.Close()
 
-	// Cargar datos iniciales
-	err = db.ExecuteCypherFile("scripts/data.cypher")
-	if err != nil {
-		log.Printf("Warning: could not initialize DB: %v", err)
+	// Si se indica un comando se ejecuta y se termina sin levantar el servidor
+	if len(os.Args) > 1 {
+		runCommand(&service, os.Args[1:])
+		return
+	}
+
+	// Las restricciones se aplican en cada arranque; el script es idempotente
+	if err := db.ExecuteCypherFile("scripts/schema.cypher"); err != nil {
+		slog.Warn("could not apply schema", "err", err)
+	}
+	// Cargar datos iniciales solo en una base sin zonas; así los cambios sobreviven a los reinicios
+	seeded, seedErr := db.SeedCypherFile("scripts/data.cypher")
+	if seedErr != nil {
+		slog.Warn("could not initialize DB", "err", seedErr)
+	} else if seeded {
+		slog.Info("Datos iniciales cargados correctamente")
 	} else {
-		log.Println("Datos iniciales cargados correctamente")
+		slog.Info("La base ya tiene zonas; no se cargan los datos iniciales")
+	}
+	if err := service.EnsureGraphLoaded(context.Background()); err != nil {
+		slog.Warn("could not load network graph", "err", err)
+	}
+
+	// /readyz falla mientras alguna de estas dependencias no esté lista
+	checker := health.NewChecker()
+	checker.Add("neo4j", func(ctx context.Context) error {
+		return db.Ping()
+	})
+	// Comprueba la restricción que crea scripts/schema.cypher y vuelve a aplicar el script si falta
+	checker.Add("schema", func(ctx context.Context) error {
+		found, err := db.HasConstraint("snapshot_version")
+		if err != nil || found {
+			return err
+		}
+		if err := db.ExecuteCypherFile("scripts/schema.cypher"); err != nil {
+			return fmt.Errorf("scripts/schema.cypher failed: %w", err)
+		}
+		return nil
+	})
+	// Si la semilla falló al arrancar se reintenta aquí; solo se carga en una base sin zonas,
+	// así que repetirla no pisa nada
+	var seedMu sync.Mutex
+	checker.Add("seed", func(ctx context.Context) error {
+		seedMu.Lock()
+		defer seedMu.Unlock()
+		if seedErr == nil {
+			return nil
+		}
+		if _, seedErr = db.SeedCypherFile("scripts/data.cypher"); seedErr != nil {
+			return fmt.Errorf("scripts/data.cypher failed: %w", seedErr)
+		}
+		slog.Info("Datos iniciales cargados correctamente")
+		return nil
+	})
+	checker.Add("graph_cache", func(ctx context.Context) error {
+		return service.EnsureGraphLoaded(ctx)
+	})
+
+	// Configurar autenticación y endpoints
+	authn, err := auth.NewAuthenticator(auth.Config{
+		Disabled:         cfg.AuthDisabled,
+		APIKeys:          cfg.APIKeys,
+		HMACSecret:       cfg.JWTSecret,
+		RSAPublicKeyPath: cfg.JWTPublicKeyPath,
+		Issuer:           cfg.JWTIssuer,
+		Audience:         cfg.JWTAudience,
+		AnonymousRole:    cfg.AnonymousRole,
+	})
+	if err != nil {
+		slog.Error("invalid authentication configuration", "err", err)
+		os.Exit(1)
+	}
+	if cfg.AuthDisabled {
+		slog.Warn("authentication is disabled")
 	}
+	router := newRouter(&service, authn, checker)
+	// El tamaño de la red se actualiza antes de cada lectura de /metrics
+	metrics.Default.OnCollect(service.CollectMetrics)
 
-	// Configurar endpoints
+	// Configurar CORS
+	c := cors.New(cors.Options{
+		AllowedOrigins:   []string{"http://localhost:5173", "http://127.0.0.1:5173"},
+		AllowedMethods:   []string{"GET", "POST", "OPTIONS", "PUT", "DELETE"},
+		AllowedHeaders:   []string{"Accept", "Content-Type", "Content-Length", "Accept-Encoding", "X-CSRF-Token", "Authorization", "X-API-Key"},
+		AllowCredentials: false, // las credenciales viajan en cabeceras, no en cookies
+		Debug:            logging.ParseLevel(cfg.LogLevel) == slog.LevelDebug,
+		Logger:           slog.NewLogLogger(slog.Default().Handler(), slog.LevelDebug),
+	})
+	// Configurar servidor HTTP con CORS
+	server := &http.Server{
+		Addr:    fmt.Sprintf(":%d", cfg.Port),
+		Handler: logging.Middleware(tracing.Middleware(metrics.Middleware(c.Handler(router)))),
+	}
+	// Shutdown espera a que las conexiones queden inactivas y un stream SSE nunca lo hace; cerrar el broker los termina
+	server.RegisterOnShutdown(service.Events.Close)
+
+	// Iniciar servidor
+	go func() {
+		slog.Info("Server starting", "port", cfg.Port)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			slog.Error("could not start server", "err", err)
+			os.Exit(1)
+		}
+	}()
+
+	// Manejar shutdown
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		slog.Error("server forced to shutdown", "err", err)
+		os.Exit(1)
+	}
+
+	slog.Info("Server exiting")
+}
+
+// Rutas de la API. Leer requiere viewer; flota, tramos y cierres dispatcher; administración admin
+func newRouter(service *services.DeliveryService, authn *auth.Authenticator, checker *health.Checker) *http.ServeMux {
 	router := http.NewServeMux()
 
-	router.HandleFunc("/api/graph", func(w http.ResponseWriter, r *http.Request) {
+	// Filtros opcionales: zone con hops y/o minutes para la vecindad de una zona, tipo_zona, label,
+	// trafico y accesible; offset y limit paginan los nodos y los links siguen a la página
+	router.HandleFunc("/api/graph", authn.Require(auth.RoleViewer, func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
-		graphData, err := service.GetGraphData()
+		query, err := parseGraphQuery(r.URL.Query())
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		graphData, err := service.QueryGraph(r.Context(), query)
+		if err != nil {
+			http.Error(w, err.Error(), statusForError(err))
 			return
 		}
 		json.NewEncoder(w).Encode(graphData)
-	})
+	}))
+
+	// GeoJSON para librerías de mapas; con ?start=&end= incluye la ruta más corta como LineString
+	router.HandleFunc("/api/graph/geojson", authn.Require(auth.RoleViewer, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/geo+json")
+		queryParams := r.URL.Query()
+		collection, err := service.ExportGeoJSON(r.Context(), queryParams.Get("start"), queryParams.Get("end"))
+		if err != nil {
+			http.Error(w, err.Error(), statusForError(err))
+			return
+		}
+		json.NewEncoder(w).Encode(collection)
+	}))
+
+	// Exporta el grafo como DOT, GraphML o CSV (?format=csv&part=nodes|edges)
+	router.HandleFunc("/api/graph/export", authn.Require(auth.RoleViewer, func(w http.ResponseWriter, r *http.Request) {
+		queryParams := r.URL.Query()
+		format := queryParams.Get("format")
+		contentType, ok := export.ContentTypes[format]
+		if !ok {
+			http.Error(w, "format must be dot, graphml or csv", http.StatusBadRequest)
+			return
+		}
+		graphData, err := service.GetGraphData(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", contentType)
+		if err := export.Write(w, format, queryParams.Get("part"), graphData); err != nil {
+			slog.ErrorContext(r.Context(), "could not export graph", "format", format, "err", err)
+		}
+	}))
 
-	router.HandleFunc("/api/zones", func(w http.ResponseWriter, r *http.Request) {
-		log.Println("zones request")
+	router.HandleFunc("/api/zones", authn.Require(auth.RoleViewer, func(w http.ResponseWriter, r *http.Request) {
+		slog.DebugContext(r.Context(), "zones request")
 		w.Header().Set("Content-Type", "application/json")
 		w.Write([]byte(`{"message": "Zonas endpoint funciona"}`))
-	})
+	}))
 
-	router.HandleFunc("/api/route", func(w http.ResponseWriter, r *http.Request) {
+	router.HandleFunc("/api/route", authn.Require(auth.RoleViewer, func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
-		log.Println("route request")
+		slog.DebugContext(r.Context(), "route request")
 		w.Write([]byte(`{"message": "Rutas endpoint funciona"}`))
-	})
+	}))
 
-	router.HandleFunc("/api/route/hightraffic", func(w http.ResponseWriter, r *http.Request) {
+	// Alias de /api/route/segments?trafico=alto
+	router.HandleFunc("/api/route/hightraffic", authn.Require(auth.RoleViewer, func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
-		route := service.RouteRepo.GetHighTrafficEdges()
+		route, err := service.GetHighTrafficRoutes(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), statusForError(err))
+			return
+		}
 		json.NewEncoder(w).Encode(map[string]interface{}{"items": route})
-	})
+	}))
 
-	router.HandleFunc("/api/zones/dijkstra", func(w http.ResponseWriter, r *http.Request) {
+	// Consulta de tramos: trafico (lista), accesible, min_/max_capacidad, min_/max_tiempo, source, target,
+	// sort y order=desc, offset y limit; group=source|target agrega por zona
+	router.HandleFunc("/api/route/segments", authn.Require(auth.RoleViewer, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		query, err := parseSegmentQuery(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		result, err := service.QuerySegments(r.Context(), query)
+		if err != nil {
+			http.Error(w, err.Error(), statusForError(err))
+			return
+		}
+		json.NewEncoder(w).Encode(result)
+	}))
+
+	router.HandleFunc("/api/zones/dijkstra", authn.Require(auth.RoleViewer, func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		queryParams := r.URL.Query()
 
 		start := queryParams.Get("start")
 		end := queryParams.Get("end")
 
-		path, cost, err := service.FindShortestPath(start, end)
+		// algorithm=astar usa la búsqueda dirigida por coordenadas; por defecto Dijkstra.
+		// version=N o at=<RFC3339> calculan la ruta sobre la red guardada en un snapshot
+		var path []string
+		var cost float64
+		var err error
+		if queryParams.Get("version") != "" || queryParams.Get("at") != "" {
+			snapshot, err := snapshotFromQuery(r.Context(), service, queryParams)
+			if err != nil {
+				http.Error(w, err.Error(), statusForError(err))
+				return
+			}
+			path, cost, err = service.FindShortestPathInSnapshot(r.Context(), snapshot, start, end, queryParams.Get("algorithm") == "astar")
+			if err != nil {
+				http.Error(w, err.Error(), statusForError(err))
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"items": path, "minutes": cost, "version": snapshot.Version})
+			return
+		}
+		if queryParams.Get("algorithm") == "astar" {
+			path, cost, err = service.FindShortestPathAStar(r.Context(), start, end)
+		} else {
+			path, cost, err = service.FindShortestPath(r.Context(), start, end)
+		}
 		if err != nil {
-			log.Println("Error marshalling graph to JSON: %v", err)
+			slog.InfoContext(r.Context(), "route not found", "start", start, "end", end, "err", err)
+			w.Header().Set("Content-Type", "text/plain")
 			w.Write([]byte(`unreachable`))
 		} else {
 			json.NewEncoder(w).Encode(map[string]interface{}{"items": path, "minutes": cost})
 		}
 
-	})
+	}))
 
-	router.HandleFunc("/api/zones/accesible", func(w http.ResponseWriter, r *http.Request) {
+	router.HandleFunc("/api/zones/accesible", authn.Require(auth.RoleViewer, func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		queryParams := r.URL.Query()
 		start := queryParams.Get("start")
 		direct := queryParams.Get("direct")
-		minutes, err := strconv.ParseFloat(queryParams.Get("minutes"), 64)
-		if err != nil {
-			log.Fatal(err)
-		}
-		fmt.Println(start)
+		slog.DebugContext(r.Context(), "accessibility request", "start", start, "direct", direct)
 		if direct == "" {
-			accesible, inaccesible := service.FindInaccesible(start)
+			accesible, inaccesible := service.FindInaccesible(r.Context(), start)
 			json.NewEncoder(w).Encode(map[string]interface{}{"accesible": accesible, "inaccesible": inaccesible})
 		} else {
-			routes := service.FindDirectAccessible(start, minutes)
+			minutes, err := strconv.ParseFloat(queryParams.Get("minutes"), 64)
+			if err != nil {
+				http.Error(w, "minutes must be a number", http.StatusBadRequest)
+				return
+			}
+			routes := service.FindDirectAccessible(r.Context(), start, minutes)
 			json.NewEncoder(w).Encode(map[string]interface{}{"from": start, "to": routes})
 		}
-	})
+	}))
 
-	// Configurar CORS
-	c := cors.New(cors.Options{
-		AllowedOrigins:   []string{"http://localhost:5173", "http://127.0.0.1:5173"},
-		AllowedMethods:   []string{"GET", "POST", "OPTIONS", "PUT", "DELETE"},
-		AllowedHeaders:   []string{"Accept", "Content-Type", "Content-Length", "Accept-Encoding", "X-CSRF-Token", "Authorization"},
-		AllowCredentials: true,
-		Debug:            true, // Solo para desarrollo
-	})
-	// Configurar servidor HTTP con CORS
-	server := &http.Server{
-		Addr:    fmt.Sprintf(":%d", cfg.Port),
-		Handler: c.Handler(router),
+	router.HandleFunc("/api/zones/locate", authn.Require(auth.RoleViewer, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		queryParams := r.URL.Query()
+		lat, errLat := strconv.ParseFloat(queryParams.Get("lat"), 64)
+		lng, errLng := strconv.ParseFloat(queryParams.Get("lng"), 64)
+		if errLat != nil || errLng != nil || lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+			http.Error(w, "lat and lng must be valid coordinates", http.StatusBadRequest)
+			return
+		}
+		location, err := service.LocateZone(r.Context(), lat, lng)
+		if err != nil {
+			http.Error(w, err.Error(), statusForError(err))
+			return
+		}
+		json.NewEncoder(w).Encode(location)
+	}))
+
+	router.HandleFunc("/api/zones/components", authn.Require(auth.RoleViewer, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		report, err := service.AnalyzeConnectivity(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(report)
+	}))
+
+	router.HandleFunc("/api/route/critical", authn.Require(auth.RoleViewer, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		// rank=population ordena por habitantes aislados, por defecto se ordena por número de zonas
+		report, err := service.FindCriticalElements(r.Context(), r.URL.Query().Get("rank"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(report)
+	}))
+
+	router.HandleFunc("/api/route/simulate", authn.Require(auth.RoleViewer, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		var req models.SimulationRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		result, err := service.SimulateClosures(r.Context(), req)
+		if errors.Is(err, services.ErrInvalidSimulation) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(result)
+	}))
+
+	router.HandleFunc("/api/zones/centrality", authn.Require(auth.RoleViewer, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		// persist=true guarda las métricas como propiedades en Neo4j y requiere admin
+		persist := r.URL.Query().Get("persist") == "true"
+		if persist && !auth.HasRole(r.Context(), auth.RoleAdmin) {
+			http.Error(w, "admin role required to persist centrality", http.StatusForbidden)
+			return
+		}
+		report, err := service.RankCentrality(r.Context(), persist)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(report)
+	}))
+
+	router.HandleFunc("/api/route/maxflow", authn.Require(auth.RoleViewer, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		queryParams := r.URL.Query()
+		result, err := service.ComputeThroughput(r.Context(), queryParams.Get("from"), queryParams.Get("to"))
+		if errors.Is(err, services.ErrZoneNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(result)
+	}))
+
+	router.HandleFunc("/api/route/plan", authn.Require(auth.RoleViewer, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		var req models.PlanRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		plan, err := service.PlanRoutes(r.Context(), req)
+		if errors.Is(err, services.ErrInvalidPlan) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(plan)
+	}))
+
+	router.HandleFunc("/api/fleet/vehicles", authn.RequireMethods(auth.RoleViewer, auth.RoleDispatcher, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		id := r.URL.Query().Get("id")
+
+		switch r.Method {
+		case http.MethodGet:
+			if id == "" {
+				vehicles, err := service.ListVehicles(r.Context(), r.URL.Query().Get("centro"))
+				if err != nil {
+					http.Error(w, err.Error(), statusForError(err))
+					return
+				}
+				json.NewEncoder(w).Encode(map[string]interface{}{"items": vehicles})
+				return
+			}
+			vehicle, err := service.GetVehicle(r.Context(), id)
+			if err != nil {
+				http.Error(w, err.Error(), statusForError(err))
+				return
+			}
+			json.NewEncoder(w).Encode(vehicle)
+		case http.MethodPost, http.MethodPut:
+			var vehicle models.Vehiculo
+			if err := json.NewDecoder(r.Body).Decode(&vehicle); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			var saved models.Vehiculo
+			var err error
+			if r.Method == http.MethodPost {
+				saved, err = service.CreateVehicle(r.Context(), vehicle)
+			} else {
+				vehicle.ID = id
+				saved, err = service.UpdateVehicle(r.Context(), vehicle)
+			}
+			if err != nil {
+				http.Error(w, err.Error(), statusForError(err))
+				return
+			}
+			json.NewEncoder(w).Encode(saved)
+		case http.MethodDelete:
+			if err := service.DeleteVehicle(r.Context(), id); err != nil {
+				http.Error(w, err.Error(), statusForError(err))
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+
+	router.HandleFunc("/api/fleet/drivers", authn.RequireMethods(auth.RoleViewer, auth.RoleDispatcher, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		id := r.URL.Query().Get("id")
+
+		switch r.Method {
+		case http.MethodGet:
+			if id == "" {
+				drivers, err := service.ListDrivers(r.Context(), r.URL.Query().Get("centro"))
+				if err != nil {
+					http.Error(w, err.Error(), statusForError(err))
+					return
+				}
+				json.NewEncoder(w).Encode(map[string]interface{}{"items": drivers})
+				return
+			}
+			driver, err := service.GetDriver(r.Context(), id)
+			if err != nil {
+				http.Error(w, err.Error(), statusForError(err))
+				return
+			}
+			json.NewEncoder(w).Encode(driver)
+		case http.MethodPost, http.MethodPut:
+			var driver models.Conductor
+			if err := json.NewDecoder(r.Body).Decode(&driver); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			var saved models.Conductor
+			var err error
+			if r.Method == http.MethodPost {
+				saved, err = service.CreateDriver(r.Context(), driver)
+			} else {
+				driver.ID = id
+				saved, err = service.UpdateDriver(r.Context(), driver)
+			}
+			if err != nil {
+				http.Error(w, err.Error(), statusForError(err))
+				return
+			}
+			json.NewEncoder(w).Encode(saved)
+		case http.MethodDelete:
+			if err := service.DeleteDriver(r.Context(), id); err != nil {
+				http.Error(w, err.Error(), statusForError(err))
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+
+	router.HandleFunc("/api/fleet/dispatch", authn.Require(auth.RoleDispatcher, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		var req models.DispatchRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		tracking, err := service.DispatchVehicle(r.Context(), req)
+		if err != nil {
+			http.Error(w, err.Error(), statusForError(err))
+			return
+		}
+		json.NewEncoder(w).Encode(tracking)
+	}))
+
+	router.HandleFunc("/api/fleet/position", authn.RequireMethods(auth.RoleViewer, auth.RoleDispatcher, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case http.MethodGet:
+			tracking, err := service.GetTracking(r.Context(), r.URL.Query().Get("id"))
+			if err != nil {
+				http.Error(w, err.Error(), statusForError(err))
+				return
+			}
+			json.NewEncoder(w).Encode(tracking)
+		case http.MethodPost:
+			var report models.PositionReport
+			if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			tracking, err := service.ReportPosition(r.Context(), report)
+			if err != nil {
+				http.Error(w, err.Error(), statusForError(err))
+				return
+			}
+			json.NewEncoder(w).Encode(tracking)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+
+	router.HandleFunc("/api/fleet/delayed", authn.Require(auth.RoleViewer, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		threshold := 0.0
+		if value := r.URL.Query().Get("threshold"); value != "" {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				http.Error(w, "threshold must be a number of minutes", http.StatusBadRequest)
+				return
+			}
+			threshold = parsed
+		}
+		vehicles, err := service.FindDelayedVehicles(r.Context(), threshold)
+		if err != nil {
+			http.Error(w, err.Error(), statusForError(err))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"items": vehicles})
+	}))
+
+	router.HandleFunc("/api/route/segment", authn.Require(auth.RoleDispatcher, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		queryParams := r.URL.Query()
+		var update models.SegmentUpdate
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		connection, err := service.UpdateSegment(r.Context(), queryParams.Get("source"), queryParams.Get("target"), update)
+		if err != nil {
+			http.Error(w, err.Error(), statusForError(err))
+			return
+		}
+		json.NewEncoder(w).Encode(connection)
+	}))
+
+	// Stream SSE de cambios en la red y la flota. Filtros opcionales: ?zone=A,B&type=traffic_changed,vehicle_moved.
+	// EventSource no envía cabeceras, así que la clave o el JWT también se aceptan en ?access_token=
+	router.HandleFunc("/api/events", authn.RequireStream(auth.RoleViewer, func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming not supported", http.StatusInternalServerError)
+			return
+		}
+		filter := events.Filter{}
+		if zones := r.URL.Query().Get("zone"); zones != "" {
+			filter.Zones = strings.Split(zones, ",")
+		}
+		if types := r.URL.Query().Get("type"); types != "" {
+			filter.Types = strings.Split(types, ",")
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		stream, unsubscribe := service.Events.Subscribe(filter)
+		defer unsubscribe()
+		keepAlive := time.NewTicker(15 * time.Second)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-keepAlive.C:
+				fmt.Fprint(w, ": keep-alive\n\n")
+				flusher.Flush()
+			case event, ok := <-stream:
+				// El broker cierra el canal al apagar el servidor
+				if !ok {
+					return
+				}
+				data, err := json.Marshal(event)
+				if err != nil {
+					slog.ErrorContext(r.Context(), "could not encode event", "type", event.Type, "err", err)
+					continue
+				}
+				fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+				flusher.Flush()
+			}
+		}
+	}))
+
+	// GET lista las versiones o, con ?version=N o ?at=<RFC3339>, retorna una completa; POST guarda la red actual
+	router.HandleFunc("/api/snapshots", authn.RequireMethods(auth.RoleViewer, auth.RoleAdmin, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		queryParams := r.URL.Query()
+		switch r.Method {
+		case http.MethodGet:
+			if queryParams.Get("version") != "" || queryParams.Get("at") != "" {
+				snapshot, err := snapshotFromQuery(r.Context(), service, queryParams)
+				if err != nil {
+					http.Error(w, err.Error(), statusForError(err))
+					return
+				}
+				json.NewEncoder(w).Encode(snapshot)
+				return
+			}
+			snapshots, err := service.ListSnapshots(r.Context())
+			if err != nil {
+				http.Error(w, err.Error(), statusForError(err))
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"items": snapshots})
+		case http.MethodPost:
+			var req models.SnapshotRequest
+			if r.ContentLength != 0 {
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+			}
+			info, err := service.CreateSnapshot(r.Context(), req.Description)
+			if err != nil {
+				http.Error(w, err.Error(), statusForError(err))
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(info)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+
+	// Restaura la red a ?version=N, guardando antes un snapshot de respaldo
+	router.HandleFunc("/api/snapshots/restore", authn.Require(auth.RoleAdmin, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		version, err := strconv.Atoi(r.URL.Query().Get("version"))
+		if err != nil || version <= 0 {
+			http.Error(w, "version must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		result, err := service.RestoreSnapshot(r.Context(), version)
+		if err != nil {
+			http.Error(w, err.Error(), statusForError(err))
+			return
+		}
+		json.NewEncoder(w).Encode(result)
+	}))
+
+	// Historial de cambios de una zona (?zone=) o de un tramo (?source=&target=), el más reciente primero
+	router.HandleFunc("/api/audit", authn.Require(auth.RoleDispatcher, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		queryParams := r.URL.Query()
+		query := models.AuditQuery{
+			Zone:   queryParams.Get("zone"),
+			Source: queryParams.Get("source"),
+			Target: queryParams.Get("target"),
+		}
+		if query.Zone == "" && (query.Source == "" || query.Target == "") {
+			http.Error(w, "zone or source and target are required", http.StatusBadRequest)
+			return
+		}
+		if limit := queryParams.Get("limit"); limit != "" {
+			parsed, err := strconv.Atoi(limit)
+			if err != nil || parsed < 0 {
+				http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+				return
+			}
+			query.Limit = parsed
+		}
+		entries, err := service.GetAuditHistory(r.Context(), query)
+		if err != nil {
+			http.Error(w, err.Error(), statusForError(err))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"items": entries})
+	}))
+
+	// Reporte de integridad de la red: lazos, tiempos inválidos, propiedades faltantes, pares con
+	// tiempos distintos, zonas aisladas o inalcanzables desde los centros
+	router.HandleFunc("/api/admin/validate", authn.Require(auth.RoleAdmin, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		report, err := service.ValidateNetwork(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), statusForError(err))
+			return
+		}
+		json.NewEncoder(w).Encode(report)
+	}))
+
+	// Importación masiva: JSON en el cuerpo o CSV multipart con los archivos "zones" y "connections".
+	// dry_run=true solo valida y reporta; strict=true rechaza también advertencias de integridad nuevas
+	router.HandleFunc("/api/admin/import", authn.Require(auth.RoleAdmin, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		data, err := readImport(r)
+		if err != nil {
+			http.Error(w, err.Error(), statusForError(err))
+			return
+		}
+		options := models.ImportOptions{BatchSize: repositories.DefaultBatchSize}
+		options.DryRun, _ = strconv.ParseBool(r.URL.Query().Get("dry_run"))
+		options.Strict, _ = strconv.ParseBool(r.URL.Query().Get("strict"))
+		report, err := service.ImportNetwork(r.Context(), data, options)
+		if err != nil {
+			http.Error(w, err.Error(), statusForError(err))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if len(report.Errors) > 0 {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+		}
+		json.NewEncoder(w).Encode(report)
+	}))
+
+	// Salud del servidor, sin autenticación para los orquestadores
+	router.HandleFunc("/healthz", checker.LiveHandler())
+	router.HandleFunc("/readyz", checker.ReadyHandler())
+
+	// Métricas en formato Prometheus, sin autenticación para que el scraper no necesite credenciales
+	router.Handle("/metrics", metrics.Default.Handler())
+
+	// Especificación OpenAPI y su página de documentación, públicas como la salud del servidor
+	router.HandleFunc("/api/openapi.json", openapi.Handler())
+	router.HandleFunc("/api/docs", openapi.DocsHandler())
+
+	return router
+}
+
+// Busca el snapshot indicado por ?version=N o por ?at=<RFC3339>
+func snapshotFromQuery(ctx context.Context, service *services.DeliveryService, values url.Values) (models.Snapshot, error) {
+	if version := values.Get("version"); version != "" {
+		parsed, err := strconv.Atoi(version)
+		if err != nil || parsed <= 0 {
+			return models.Snapshot{}, fmt.Errorf("%w: version must be a positive integer", services.ErrInvalidSnapshotQuery)
+		}
+		return service.GetSnapshot(ctx, parsed, time.Time{})
 	}
+	at, err := time.Parse(time.RFC3339, values.Get("at"))
+	if err != nil {
+		return models.Snapshot{}, fmt.Errorf("%w: at must be an RFC3339 timestamp", services.ErrInvalidSnapshotQuery)
+	}
+	return service.GetSnapshot(ctx, 0, at)
+}
 
-	// Iniciar servidor
-	go func() {
-		log.Printf("Server starting on port %d", cfg.Port)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Could not start server: %v", err)
+// Lee los filtros de /api/graph. Las listas van separadas por comas
+func parseGraphQuery(values url.Values) (models.GraphQuery, error) {
+	query := models.GraphQuery{
+		Center: values.Get("zone"),
+		Label:  values.Get("label"),
+	}
+	if tipos := values.Get("tipo_zona"); tipos != "" {
+		query.TipoZona = strings.Split(tipos, ",")
+	}
+	if traficos := values.Get("trafico"); traficos != "" {
+		query.Trafico = strings.Split(traficos, ",")
+	}
+	if accesible := values.Get("accesible"); accesible != "" {
+		parsed, err := strconv.ParseBool(accesible)
+		if err != nil {
+			return query, fmt.Errorf("accesible must be true or false")
 		}
-	}()
+		query.Accesible = &parsed
+	}
+	for name, target := range map[string]*int{"hops": &query.Hops, "offset": &query.Offset, "limit": &query.Limit} {
+		if value := values.Get(name); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				return query, fmt.Errorf("%s must be an integer", name)
+			}
+			*target = parsed
+		}
+	}
+	if minutes := values.Get("minutes"); minutes != "" {
+		parsed, err := strconv.ParseFloat(minutes, 64)
+		if err != nil {
+			return query, fmt.Errorf("minutes must be a number")
+		}
+		query.Minutes = parsed
+	}
+	return query, nil
+}
 
-	// Manejar shutdown
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+// Lee los filtros de /api/route/segments
+func parseSegmentQuery(values url.Values) (models.SegmentQuery, error) {
+	query := models.SegmentQuery{
+		Source:  values.Get("source"),
+		Target:  values.Get("target"),
+		SortBy:  values.Get("sort"),
+		Desc:    values.Get("order") == "desc",
+		GroupBy: values.Get("group"),
+	}
+	if traficos := values.Get("trafico"); traficos != "" {
+		query.Trafico = strings.Split(traficos, ",")
+	}
+	if accesible := values.Get("accesible"); accesible != "" {
+		parsed, err := strconv.ParseBool(accesible)
+		if err != nil {
+			return query, fmt.Errorf("accesible must be true or false")
+		}
+		query.Accesible = &parsed
+	}
+	for name, target := range map[string]**int{"min_capacidad": &query.MinCapacidad, "max_capacidad": &query.MaxCapacidad} {
+		if value := values.Get(name); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				return query, fmt.Errorf("%s must be an integer", name)
+			}
+			*target = &parsed
+		}
+	}
+	for name, target := range map[string]**float64{"min_tiempo": &query.MinTiempo, "max_tiempo": &query.MaxTiempo} {
+		if value := values.Get(name); value != "" {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return query, fmt.Errorf("%s must be a number", name)
+			}
+			*target = &parsed
+		}
+	}
+	for name, target := range map[string]*int{"offset": &query.Offset, "limit": &query.Limit} {
+		if value := values.Get(name); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				return query, fmt.Errorf("%s must be an integer", name)
+			}
+			*target = parsed
+		}
+	}
+	return query, nil
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+// Lee los datos de importación según format (json por defecto o csv)
+func readImport(r *http.Request) (models.ImportData, error) {
+	if r.URL.Query().Get("format") != "csv" {
+		return importer.ParseJSON(r.Body)
+	}
 
-	if err := server.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+	data := models.ImportData{}
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		return data, fmt.Errorf("%w: %v", importer.ErrInvalidFile, err)
+	}
+	if file, _, err := r.FormFile("zones"); err == nil {
+		defer file.Close()
+		if data.Zones, err = importer.ParseZonesCSV(file); err != nil {
+			return data, err
+		}
 	}
+	if file, _, err := r.FormFile("connections"); err == nil {
+		defer file.Close()
+		if data.Connections, err = importer.ParseConnectionsCSV(file); err != nil {
+			return data, err
+		}
+	}
+	return data, nil
+}
 
-	log.Println("Server exiting")
+// Traduce los errores conocidos de servicios y repositorios a su código HTTP
+func statusForError(err error) int {
+	switch {
+	case errors.Is(err, repositories.ErrNotFound), errors.Is(err, services.ErrZoneNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repositories.ErrCapacityExceeded):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrInvalidFleet),
+		errors.Is(err, services.ErrInvalidPlan),
+		errors.Is(err, services.ErrInvalidSimulation),
+		errors.Is(err, services.ErrInvalidSegment),
+		errors.Is(err, services.ErrInvalidGraphQuery),
+		errors.Is(err, services.ErrInvalidSegmentQuery),
+		errors.Is(err, services.ErrInvalidSnapshotQuery),
+		errors.Is(err, importer.ErrInvalidFile):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
 }
@@ -0,0 +1,370 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"neo4j_delivery/internal/auth"
+	"neo4j_delivery/internal/events"
+	"neo4j_delivery/internal/health"
+	"neo4j_delivery/internal/models"
+	"neo4j_delivery/internal/openapi"
+	"neo4j_delivery/internal/repositories"
+	"neo4j_delivery/internal/services"
+)
+
+// Red de prueba: A -> B -> C -> A en ciclo, D solo alcanzable por un tramo cerrado y E aislada
+func testGraph() models.Graph {
+	return models.Graph{
+		"A": {{Item: "B", Cost: 5, Accesible: true}},
+		"B": {{Item: "C", Cost: 10, Accesible: true}, {Item: "D", Cost: 3, Accesible: false}},
+		"C": {{Item: "A", Cost: 7, Accesible: true}},
+		"D": {{Item: "A", Cost: 4, Accesible: true}},
+		"E": {},
+	}
+}
+
+// Repositorios en memoria sobre testGraph. Los métodos que no se redefinen vienen de la
+// interfaz nula y fallan si un handler los usa
+type fakeZones struct {
+	services.ZoneStore
+}
+
+func (fakeZones) GetAllAsGraph(ctx context.Context) (models.Graph, error) {
+	return testGraph(), nil
+}
+
+func (fakeZones) FindZoneRecords(ctx context.Context) ([]models.ZoneRecord, error) {
+	records := []models.ZoneRecord{}
+	for i, name := range []string{"A", "B", "C", "D", "E"} {
+		records = append(records, models.ZoneRecord{ID: int64(i), Nombre: name, Centro: name == "A"})
+	}
+	return records, nil
+}
+
+type fakeRoutes struct {
+	services.RouteStore
+}
+
+func (fakeRoutes) FindSegmentRecords(ctx context.Context) ([]models.SegmentRecord, error) {
+	records := []models.SegmentRecord{}
+	for source, edges := range testGraph() {
+		for _, edge := range edges {
+			records = append(records, models.SegmentRecord{
+				ID: int64(len(records)), Source: source, Target: edge.Item, TiempoMinutos: edge.Cost, Accesible: edge.Accesible,
+			})
+		}
+	}
+	return records, nil
+}
+
+func (fakeRoutes) UpdateSegment(ctx context.Context, source, target string, update models.SegmentUpdate) (models.Connection, models.Connection, error) {
+	for _, edge := range testGraph()[source] {
+		if edge.Item != target {
+			continue
+		}
+		before := models.Connection{Source: source, Target: target, Tiempo: int(edge.Cost), Trafico: "bajo", Direccion: "uni", Accesible: edge.Accesible}
+		after := before
+		if update.Trafico != nil {
+			after.Trafico = *update.Trafico
+		}
+		if update.Accesible != nil {
+			after.Accesible = *update.Accesible
+		}
+		return before, after, nil
+	}
+	return models.Connection{}, models.Connection{}, fmt.Errorf("%w: segment %s -> %s", repositories.ErrNotFound, source, target)
+}
+
+// Flota con un único centro, A, y el centro B ya sin capacidad para más vehículos
+type fakeFleet struct {
+	services.FleetStore
+}
+
+func (fakeFleet) CreateVehicle(ctx context.Context, vehicle models.Vehiculo) (models.Vehiculo, error) {
+	switch vehicle.Centro {
+	case "A":
+		vehicle.ID = "vehiculo-1"
+		return vehicle, nil
+	case "B":
+		return models.Vehiculo{}, fmt.Errorf("%w: center %q", repositories.ErrCapacityExceeded, vehicle.Centro)
+	}
+	return models.Vehiculo{}, fmt.Errorf("%w: center %q", repositories.ErrNotFound, vehicle.Centro)
+}
+
+func (fakeFleet) UpdateVehicle(ctx context.Context, vehicle models.Vehiculo) (models.Vehiculo, error) {
+	return models.Vehiculo{}, fmt.Errorf("%w: vehicle %q or center %q", repositories.ErrNotFound, vehicle.ID, vehicle.Centro)
+}
+
+// Servidor con los handlers reales sobre repositorios en memoria, sin Neo4j
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	service := &services.DeliveryService{ZoneRepo: fakeZones{}, RouteRepo: fakeRoutes{}, FleetRepo: fakeFleet{}, Events: events.NewBroker()}
+	authn, err := auth.NewAuthenticator(auth.Config{APIKeys: "viewer-key:viewer,dispatcher-key:dispatcher,admin-key:admin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checker := health.NewChecker()
+	checker.Add("graph_cache", service.EnsureGraphLoaded)
+	server := httptest.NewServer(newRouter(service, authn, checker))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestResponsesMatchSpec(t *testing.T) {
+	spec := openapi.Spec()
+	server := newTestServer(t)
+
+	const (
+		vehicle       = `{"placa": "ABC-123", "tipo": "moto", "capacidad_carga": 50, "centro": "A"}`
+		validImport   = `{"zones": [{"nombre": "F", "tipo_zona": "comercial"}], "connections": [{"source": "A", "target": "F", "tiempo_minutos": 6}, {"source": "F", "target": "A", "tiempo_minutos": 6}]}`
+		invalidImport = `{"connections": [{"source": "A", "target": "Z", "tiempo_minutos": 0}]}`
+	)
+	cases := []struct {
+		name   string
+		method string
+		path   string // ruta de la especificación
+		query  string
+		key    string
+		body   string
+		status int
+	}{
+		{"health", http.MethodGet, "/healthz", "", "", "", http.StatusOK},
+		{"ready", http.MethodGet, "/readyz", "", "", "", http.StatusOK},
+		{"spec", http.MethodGet, "/api/openapi.json", "", "", "", http.StatusOK},
+		{"docs", http.MethodGet, "/api/docs", "", "", "", http.StatusOK},
+		{"metrics", http.MethodGet, "/metrics", "", "", "", http.StatusOK},
+		{"shortest path", http.MethodGet, "/api/zones/dijkstra", "start=A&end=C", "viewer-key", "", http.StatusOK},
+		{"unreachable", http.MethodGet, "/api/zones/dijkstra", "start=A&end=E", "viewer-key", "", http.StatusOK},
+		{"accessible zones", http.MethodGet, "/api/zones/accesible", "start=A", "viewer-key", "", http.StatusOK},
+		{"direct routes", http.MethodGet, "/api/zones/accesible", "start=A&direct=1&minutes=30", "viewer-key", "", http.StatusOK},
+		{"direct routes with bad minutes", http.MethodGet, "/api/zones/accesible", "start=A&direct=1&minutes=x", "viewer-key", "", http.StatusBadRequest},
+		{"centrality", http.MethodGet, "/api/zones/centrality", "", "viewer-key", "", http.StatusOK},
+		{"persisting centrality needs admin", http.MethodGet, "/api/zones/centrality", "persist=true", "viewer-key", "", http.StatusForbidden},
+		{"without credentials", http.MethodGet, "/api/zones/accesible", "start=A", "", "", http.StatusUnauthorized},
+		{"unknown key", http.MethodGet, "/api/zones/dijkstra", "start=A&end=C", "other-key", "", http.StatusUnauthorized},
+
+		{"create vehicle", http.MethodPost, "/api/fleet/vehicles", "", "dispatcher-key", vehicle, http.StatusOK},
+		{"create vehicle as viewer", http.MethodPost, "/api/fleet/vehicles", "", "viewer-key", vehicle, http.StatusForbidden},
+		{"create vehicle with malformed body", http.MethodPost, "/api/fleet/vehicles", "", "dispatcher-key", `{"placa": `, http.StatusBadRequest},
+		{"create vehicle without placa", http.MethodPost, "/api/fleet/vehicles", "", "dispatcher-key", `{"capacidad_carga": 50, "centro": "A"}`, http.StatusBadRequest},
+		{"create vehicle in unknown center", http.MethodPost, "/api/fleet/vehicles", "", "dispatcher-key", strings.Replace(vehicle, `"A"`, `"Z"`, 1), http.StatusNotFound},
+		{"create vehicle in full center", http.MethodPost, "/api/fleet/vehicles", "", "dispatcher-key", strings.Replace(vehicle, `"A"`, `"B"`, 1), http.StatusConflict},
+		{"update unknown vehicle", http.MethodPut, "/api/fleet/vehicles", "id=missing", "dispatcher-key", vehicle, http.StatusNotFound},
+		{"update segment", http.MethodPut, "/api/route/segment", "source=A&target=B", "dispatcher-key", `{"trafico_actual": "alto"}`, http.StatusOK},
+		{"update segment with unknown traffic", http.MethodPut, "/api/route/segment", "source=A&target=B", "dispatcher-key", `{"trafico_actual": "extremo"}`, http.StatusBadRequest},
+		{"update unknown segment", http.MethodPut, "/api/route/segment", "source=A&target=E", "dispatcher-key", `{"accesible": false}`, http.StatusNotFound},
+		{"import dry run", http.MethodPost, "/api/admin/import", "dry_run=true", "admin-key", validImport, http.StatusOK},
+		{"import with errors", http.MethodPost, "/api/admin/import", "dry_run=true", "admin-key", invalidImport, http.StatusUnprocessableEntity},
+		{"import with malformed body", http.MethodPost, "/api/admin/import", "dry_run=true", "admin-key", `[`, http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			target := server.URL + tc.path
+			if tc.query != "" {
+				target += "?" + tc.query
+			}
+			var body io.Reader
+			if tc.body != "" {
+				body = strings.NewReader(tc.body)
+			}
+			request, err := http.NewRequest(tc.method, target, body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.body != "" {
+				request.Header.Set("Content-Type", "application/json")
+			}
+			if tc.key != "" {
+				request.Header.Set("X-API-Key", tc.key)
+			}
+			response, err := http.DefaultClient.Do(request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer response.Body.Close()
+			responseBody, err := io.ReadAll(response.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if response.StatusCode != tc.status {
+				t.Fatalf("status %d, want %d: %s", response.StatusCode, tc.status, responseBody)
+			}
+			for _, problem := range checkResponse(spec, tc.method, tc.path, response, responseBody) {
+				t.Error(problem)
+			}
+		})
+	}
+}
+
+// Revisa que la especificación documente el código y el tipo de contenido de la respuesta,
+// y que el cuerpo cumpla el esquema
+func checkResponse(spec *openapi.Document, method, path string, response *http.Response, body []byte) []string {
+	operation := spec.Paths[path][strings.ToLower(method)]
+	if operation == nil {
+		return []string{fmt.Sprintf("%s %s is not in the spec", method, path)}
+	}
+	documented, ok := operation.Responses[strconv.Itoa(response.StatusCode)]
+	if !ok {
+		return []string{fmt.Sprintf("status %d is not documented", response.StatusCode)}
+	}
+	contentType, _, err := mime.ParseMediaType(response.Header.Get("Content-Type"))
+	if err != nil {
+		return []string{fmt.Sprintf("invalid Content-Type %q", response.Header.Get("Content-Type"))}
+	}
+	var schema *openapi.Schema
+	types := []string{}
+	for name, media := range documented.Content {
+		if parsed, _, _ := mime.ParseMediaType(name); parsed == contentType {
+			schema = media.Schema
+		}
+		types = append(types, name)
+	}
+	if schema == nil {
+		return []string{fmt.Sprintf("Content-Type %s is not documented for status %d (documented: %v)", contentType, response.StatusCode, types)}
+	}
+
+	var value interface{} = string(body)
+	if contentType == "application/json" {
+		decoder := json.NewDecoder(strings.NewReader(string(body)))
+		decoder.UseNumber()
+		if err := decoder.Decode(&value); err != nil {
+			return []string{fmt.Sprintf("body is not JSON: %v: %s", err, body)}
+		}
+	}
+	return validate(spec, schema, value, "body")
+}
+
+// Valida value contra schema resolviendo $ref. Cubre lo que genera el paquete openapi:
+// type, format date-time, enum, required, properties, additionalProperties, items, nullable, allOf y oneOf.
+// Un objeto con properties no admite otras claves, para detectar campos que el esquema no nombra
+func validate(spec *openapi.Document, schema *openapi.Schema, value interface{}, at string) []string {
+	if schema.Ref != "" {
+		name := strings.TrimPrefix(schema.Ref, "#/components/schemas/")
+		resolved, ok := spec.Components.Schemas[name]
+		if !ok {
+			return []string{fmt.Sprintf("%s: unknown $ref %s", at, schema.Ref)}
+		}
+		return validate(spec, resolved, value, at)
+	}
+	if value == nil {
+		if schema.Nullable || (schema.Type == "" && schema.AllOf == nil && schema.OneOf == nil) {
+			return nil
+		}
+		return []string{fmt.Sprintf("%s: null is not allowed", at)}
+	}
+
+	var problems []string
+	for _, part := range schema.AllOf {
+		problems = append(problems, validate(spec, part, value, at)...)
+	}
+	if schema.OneOf != nil {
+		matches := 0
+		for _, option := range schema.OneOf {
+			if len(validate(spec, option, value, at)) == 0 {
+				matches++
+			}
+		}
+		if matches != 1 {
+			problems = append(problems, fmt.Sprintf("%s: matches %d oneOf schemas, want 1", at, matches))
+		}
+	}
+
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return append(problems, fmt.Sprintf("%s: %T is not an object", at, value))
+		}
+		for _, name := range schema.Required {
+			if _, exists := object[name]; !exists {
+				problems = append(problems, fmt.Sprintf("%s: missing required property %q", at, name))
+			}
+		}
+		for name, property := range object {
+			if propertySchema, ok := schema.Properties[name]; ok {
+				problems = append(problems, validate(spec, propertySchema, property, at+"."+name)...)
+			} else if schema.AdditionalProperties != nil {
+				problems = append(problems, validate(spec, schema.AdditionalProperties, property, at+"."+name)...)
+			} else if schema.Properties != nil {
+				problems = append(problems, fmt.Sprintf("%s: property %q is not in the schema", at, name))
+			}
+		}
+	case "array":
+		array, ok := value.([]interface{})
+		if !ok {
+			return append(problems, fmt.Sprintf("%s: %T is not an array", at, value))
+		}
+		if schema.MinItems != nil && len(array) < *schema.MinItems || schema.MaxItems != nil && len(array) > *schema.MaxItems {
+			problems = append(problems, fmt.Sprintf("%s: %d items out of bounds", at, len(array)))
+		}
+		for i, item := range array {
+			problems = append(problems, validate(spec, schema.Items, item, fmt.Sprintf("%s[%d]", at, i))...)
+		}
+	case "string":
+		text, ok := value.(string)
+		if !ok {
+			return append(problems, fmt.Sprintf("%s: %T is not a string", at, value))
+		}
+		if schema.Enum != nil && !slices.Contains(schema.Enum, text) {
+			problems = append(problems, fmt.Sprintf("%s: %q is not one of %v", at, text, schema.Enum))
+		}
+		if schema.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, text); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %q is not a date-time", at, text))
+			}
+		}
+	case "integer":
+		number, ok := value.(json.Number)
+		if _, err := number.Int64(); !ok || err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v is not an integer", at, value))
+		}
+	case "number":
+		if _, ok := value.(json.Number); !ok {
+			problems = append(problems, fmt.Sprintf("%s: %T is not a number", at, value))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			problems = append(problems, fmt.Sprintf("%s: %T is not a boolean", at, value))
+		}
+	}
+	return problems
+}
+
+// El validador debe rechazar una ruta con las claves que producían las etiquetas json mal escritas
+func TestValidateRejectsUndocumentedKeys(t *testing.T) {
+	spec := openapi.Spec()
+	route := &openapi.Schema{Ref: "#/components/schemas/Route"}
+	var value interface{}
+	decoder := json.NewDecoder(strings.NewReader(`{"path": ["A", "B"], "Time": 5, "Target": "B"}`))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		t.Fatal(err)
+	}
+	if problems := validate(spec, route, value, "route"); len(problems) == 0 {
+		t.Error("a Route with Time and Target keys passed validation")
+	}
+
+	encoded, err := json.Marshal(models.Route{Path: []string{"A", "B"}, Time: 5, Target: "B"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoder = json.NewDecoder(strings.NewReader(string(encoded)))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		t.Fatal(err)
+	}
+	for _, problem := range validate(spec, route, value, "route") {
+		t.Error(problem)
+	}
+}
@@ -0,0 +1,238 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Roles de menor a mayor privilegio; cada rol incluye los permisos de los anteriores
+const (
+	RoleViewer     = "viewer"
+	RoleDispatcher = "dispatcher"
+	RoleAdmin      = "admin"
+)
+
+var roleRanks = map[string]int{
+	RoleViewer:     1,
+	RoleDispatcher: 2,
+	RoleAdmin:      3,
+}
+
+const (
+	MethodAPIKey    = "api_key"
+	MethodJWT       = "jwt"
+	MethodAnonymous = "anonymous"
+	MethodCLI       = "cli"
+)
+
+var (
+	ErrUnauthenticated = errors.New("authentication required")
+	ErrForbidden       = errors.New("insufficient role")
+)
+
+// Identidad de quien hace la petición
+type Principal struct {
+	Subject string `json:"subject"`
+	Role    string `json:"role"`
+	Method  string `json:"method"`
+}
+
+type Config struct {
+	Disabled bool
+	// Claves con formato clave:rol[:nombre], separadas por comas
+	APIKeys string
+	// Secreto compartido para tokens HS256
+	HMACSecret string
+	// Ruta a la clave pública PEM para tokens RS256
+	RSAPublicKeyPath string
+	Issuer           string
+	Audience         string
+	// Rol de las peticiones sin credenciales; vacío las rechaza
+	AnonymousRole string
+}
+
+type Authenticator struct {
+	disabled      bool
+	apiKeys       map[[sha256.Size]byte]Principal
+	hmacSecret    []byte
+	rsaKey        *rsa.PublicKey
+	issuer        string
+	audience      string
+	anonymousRole string
+	now           func() time.Time
+}
+
+func NewAuthenticator(cfg Config) (*Authenticator, error) {
+	a := &Authenticator{
+		disabled:      cfg.Disabled,
+		apiKeys:       make(map[[sha256.Size]byte]Principal),
+		issuer:        cfg.Issuer,
+		audience:      cfg.Audience,
+		anonymousRole: cfg.AnonymousRole,
+		now:           time.Now,
+	}
+	if a.disabled {
+		return a, nil
+	}
+
+	if a.anonymousRole != "" && !ValidRole(a.anonymousRole) {
+		return nil, fmt.Errorf("unknown anonymous role %q", a.anonymousRole)
+	}
+	for _, entry := range strings.Split(cfg.APIKeys, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		fields := strings.SplitN(entry, ":", 3)
+		if len(fields) < 2 || fields[0] == "" || !ValidRole(fields[1]) {
+			return nil, fmt.Errorf("API key entries must be key:role[:name] with role viewer, dispatcher or admin")
+		}
+		name := "api-key"
+		if len(fields) == 3 && fields[2] != "" {
+			name = fields[2]
+		}
+		a.apiKeys[sha256.Sum256([]byte(fields[0]))] = Principal{Subject: name, Role: fields[1], Method: MethodAPIKey}
+	}
+	if cfg.HMACSecret != "" {
+		a.hmacSecret = []byte(cfg.HMACSecret)
+	}
+	if cfg.RSAPublicKeyPath != "" {
+		key, err := LoadRSAPublicKey(cfg.RSAPublicKeyPath)
+		if err != nil {
+			return nil, err
+		}
+		a.rsaKey = key
+	}
+	if len(a.apiKeys) == 0 && a.hmacSecret == nil && a.rsaKey == nil && a.anonymousRole == "" {
+		return nil, errors.New("authentication is enabled but no API keys, JWT keys or anonymous role are configured")
+	}
+	return a, nil
+}
+
+func ValidRole(role string) bool {
+	_, ok := roleRanks[role]
+	return ok
+}
+
+// Indica si role alcanza el rol mínimo required
+func Allows(role, required string) bool {
+	return roleRanks[role] >= roleRanks[required]
+}
+
+// Identifica la petición por X-API-Key o por Authorization: Bearer <jwt>
+func (a *Authenticator) Authenticate(r *http.Request) (Principal, error) {
+	if a.disabled {
+		return Principal{Subject: "anonymous", Role: RoleAdmin, Method: MethodAnonymous}, nil
+	}
+
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		principal, ok := a.apiKeys[sha256.Sum256([]byte(key))]
+		if !ok {
+			return Principal{}, fmt.Errorf("%w: unknown API key", ErrUnauthenticated)
+		}
+		return principal, nil
+	}
+
+	if header := r.Header.Get("Authorization"); header != "" {
+		token, found := strings.CutPrefix(header, "Bearer ")
+		if !found {
+			return Principal{}, fmt.Errorf("%w: Authorization must be a Bearer token", ErrUnauthenticated)
+		}
+		return a.authenticateJWT(strings.TrimSpace(token))
+	}
+
+	if a.anonymousRole != "" {
+		return Principal{Subject: "anonymous", Role: a.anonymousRole, Method: MethodAnonymous}, nil
+	}
+	return Principal{}, ErrUnauthenticated
+}
+
+func (a *Authenticator) authenticateJWT(token string) (Principal, error) {
+	claims, err := a.verifyJWT(token, a.now())
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: %v", ErrUnauthenticated, err)
+	}
+	if !ValidRole(claims.Role) {
+		return Principal{}, fmt.Errorf("%w: token has unknown role %q", ErrUnauthenticated, claims.Role)
+	}
+	return Principal{Subject: claims.Subject, Role: claims.Role, Method: MethodJWT}, nil
+}
+
+// Parámetro de consulta con el que RequireStream acepta la credencial
+const AccessTokenParam = "access_token"
+
+// Como Authenticate, pero sin cabeceras de credenciales acepta una clave o un JWT en ?access_token=.
+// Un EventSource del navegador no puede enviar cabeceras, así que es la única forma de autenticarlo
+func (a *Authenticator) AuthenticateStream(r *http.Request) (Principal, error) {
+	token := r.URL.Query().Get(AccessTokenParam)
+	if a.disabled || token == "" || r.Header.Get("X-API-Key") != "" || r.Header.Get("Authorization") != "" {
+		return a.Authenticate(r)
+	}
+	if principal, ok := a.apiKeys[sha256.Sum256([]byte(token))]; ok {
+		return principal, nil
+	}
+	if a.hmacSecret == nil && a.rsaKey == nil {
+		return Principal{}, fmt.Errorf("%w: unknown API key", ErrUnauthenticated)
+	}
+	return a.authenticateJWT(token)
+}
+
+type contextKey struct{}
+
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, principal)
+}
+
+// Principal autenticado de la petición, si lo hay
+func FromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(contextKey{}).(Principal)
+	return principal, ok
+}
+
+// Indica si la petición en curso tiene al menos el rol required
+func HasRole(ctx context.Context, required string) bool {
+	principal, ok := FromContext(ctx)
+	return ok && Allows(principal.Role, required)
+}
+
+// Require autentica la petición y exige el rol mínimo antes de llamar a next
+func (a *Authenticator) Require(role string, next http.HandlerFunc) http.HandlerFunc {
+	return a.RequireMethods(role, role, next)
+}
+
+// RequireMethods exige readRole para GET y HEAD y writeRole para el resto de métodos
+func (a *Authenticator) RequireMethods(readRole, writeRole string, next http.HandlerFunc) http.HandlerFunc {
+	return a.require(a.Authenticate, readRole, writeRole, next)
+}
+
+// RequireStream es Require para streams que abre un EventSource; acepta además ?access_token=.
+// Solo debe usarse en esas rutas, porque la URL con la credencial puede quedar en historiales y proxies
+func (a *Authenticator) RequireStream(role string, next http.HandlerFunc) http.HandlerFunc {
+	return a.require(a.AuthenticateStream, role, role, next)
+}
+
+func (a *Authenticator) require(authenticate func(*http.Request) (Principal, error), readRole, writeRole string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, err := authenticate(r)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="neo4j_delivery"`)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		required := writeRole
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			required = readRole
+		}
+		if !Allows(principal.Role, required) {
+			http.Error(w, fmt.Sprintf("%v: %s role required", ErrForbidden, required), http.StatusForbidden)
+			return
+		}
+		next(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+	}
+}
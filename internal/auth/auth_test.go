@@ -0,0 +1,370 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSecret = "test-secret"
+
+var testNow = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+var (
+	rsaKey     *rsa.PrivateKey
+	rsaKeyPath string
+)
+
+func TestMain(m *testing.M) {
+	var err error
+	rsaKey, err = rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	dir, err := os.MkdirTemp("", "auth-test")
+	if err != nil {
+		panic(err)
+	}
+	rsaKeyPath = filepath.Join(dir, "public.pem")
+	der, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		panic(err)
+	}
+	if err := os.WriteFile(rsaKeyPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600); err != nil {
+		panic(err)
+	}
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func encodeSegment(t *testing.T, value interface{}) string {
+	t.Helper()
+	data, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Arma un token con la cabecera alg indicada y lo firma con HS256, RS256 o sin firma
+func token(t *testing.T, alg string, claims map[string]interface{}, sign func(signed []byte) []byte) string {
+	t.Helper()
+	signed := encodeSegment(t, map[string]string{"alg": alg, "typ": "JWT"}) + "." + encodeSegment(t, claims)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sign([]byte(signed)))
+}
+
+func signHS256(secret []byte) func([]byte) []byte {
+	return func(signed []byte) []byte {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(signed)
+		return mac.Sum(nil)
+	}
+}
+
+func signRS256(t *testing.T) func([]byte) []byte {
+	return func(signed []byte) []byte {
+		digest := sha256.Sum256(signed)
+		signature, err := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signature
+	}
+}
+
+func noSignature([]byte) []byte { return nil }
+
+func validClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"sub":  "ana",
+		"role": RoleDispatcher,
+		"iss":  "delivery-auth",
+		"aud":  []string{"other", "delivery-api"},
+		"exp":  testNow.Add(time.Hour).Unix(),
+	}
+}
+
+func withClaim(name string, value interface{}) map[string]interface{} {
+	claims := validClaims()
+	if value == nil {
+		delete(claims, name)
+	} else {
+		claims[name] = value
+	}
+	return claims
+}
+
+func newTestAuthenticator(t *testing.T, cfg Config) *Authenticator {
+	t.Helper()
+	a, err := NewAuthenticator(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.now = func() time.Time { return testNow }
+	return a
+}
+
+func bearer(token string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/api/zones", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	return r
+}
+
+func TestAuthenticateJWT(t *testing.T) {
+	hmacOnly := newTestAuthenticator(t, Config{HMACSecret: testSecret, Issuer: "delivery-auth", Audience: "delivery-api"})
+	rsaOnly := newTestAuthenticator(t, Config{RSAPublicKeyPath: rsaKeyPath, Issuer: "delivery-auth", Audience: "delivery-api"})
+	publicDER := x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey)
+	skew := int64(clockSkew / time.Second)
+
+	cases := []struct {
+		name    string
+		auth    *Authenticator
+		token   string
+		wantErr string
+	}{
+		{"valid HS256", hmacOnly, token(t, "HS256", validClaims(), signHS256([]byte(testSecret))), ""},
+		{"valid RS256", rsaOnly, token(t, "RS256", validClaims(), signRS256(t)), ""},
+		{"single audience", hmacOnly, token(t, "HS256", withClaim("aud", "delivery-api"), signHS256([]byte(testSecret))), ""},
+		{"bad HS256 signature", hmacOnly, token(t, "HS256", validClaims(), signHS256([]byte("other-secret"))), "invalid token signature"},
+		{"bad RS256 signature", rsaOnly, token(t, "RS256", validClaims(), signHS256([]byte(testSecret))), "invalid token signature"},
+		{"tampered claims", hmacOnly, tamper(t, token(t, "HS256", validClaims(), signHS256([]byte(testSecret)))), "invalid token signature"},
+		{"alg none", hmacOnly, token(t, "none", validClaims(), noSignature), `unsupported token algorithm "none"`},
+		{"alg none without key", rsaOnly, token(t, "none", validClaims(), noSignature), `unsupported token algorithm "none"`},
+		// Firmar con HS256 usando la clave pública como secreto no debe pasar donde solo se acepta RS256
+		{"HS256 signed with the public key", rsaOnly, token(t, "HS256", validClaims(), signHS256(publicDER)), "HS256 tokens are not accepted"},
+		{"RS256 where only HS256 is accepted", hmacOnly, token(t, "RS256", validClaims(), signRS256(t)), "RS256 tokens are not accepted"},
+		{"missing exp", hmacOnly, token(t, "HS256", withClaim("exp", nil), signHS256([]byte(testSecret))), "token has no expiration"},
+		{"expired", hmacOnly, token(t, "HS256", withClaim("exp", testNow.Add(-time.Hour).Unix()), signHS256([]byte(testSecret))), "token expired"},
+		{"expired within the clock skew", hmacOnly, token(t, "HS256", withClaim("exp", testNow.Unix()-skew), signHS256([]byte(testSecret))), ""},
+		{"expired just past the clock skew", hmacOnly, token(t, "HS256", withClaim("exp", testNow.Unix()-skew-1), signHS256([]byte(testSecret))), "token expired"},
+		{"nbf within the clock skew", hmacOnly, token(t, "HS256", withClaim("nbf", testNow.Unix()+skew), signHS256([]byte(testSecret))), ""},
+		{"nbf past the clock skew", hmacOnly, token(t, "HS256", withClaim("nbf", testNow.Unix()+skew+1), signHS256([]byte(testSecret))), "token not valid yet"},
+		{"wrong issuer", hmacOnly, token(t, "HS256", withClaim("iss", "someone-else"), signHS256([]byte(testSecret))), "unexpected token issuer"},
+		{"missing issuer", hmacOnly, token(t, "HS256", withClaim("iss", nil), signHS256([]byte(testSecret))), "unexpected token issuer"},
+		{"wrong audience", hmacOnly, token(t, "HS256", withClaim("aud", "other"), signHS256([]byte(testSecret))), "unexpected token audience"},
+		{"unknown role", hmacOnly, token(t, "HS256", withClaim("role", "superuser"), signHS256([]byte(testSecret))), `token has unknown role "superuser"`},
+		{"missing role", hmacOnly, token(t, "HS256", withClaim("role", nil), signHS256([]byte(testSecret))), `token has unknown role ""`},
+		{"malformed", hmacOnly, "not-a-token", "malformed token"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			principal, err := tc.auth.Authenticate(bearer(tc.token))
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Authenticate: %v", err)
+				}
+				want := Principal{Subject: "ana", Role: RoleDispatcher, Method: MethodJWT}
+				if principal != want {
+					t.Errorf("principal = %+v, want %+v", principal, want)
+				}
+				return
+			}
+			if !errors.Is(err, ErrUnauthenticated) {
+				t.Fatalf("error = %v, want ErrUnauthenticated", err)
+			}
+			if !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("error = %q, want it to contain %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+// Cambia el rol de los claims sin volver a firmar
+func tamper(t *testing.T, token string) string {
+	parts := strings.Split(token, ".")
+	parts[1] = encodeSegment(t, withClaim("role", RoleAdmin))
+	return strings.Join(parts, ".")
+}
+
+func TestAuthenticateHeaders(t *testing.T) {
+	a := newTestAuthenticator(t, Config{APIKeys: "viewer-key:viewer:panel, admin-key:admin", HMACSecret: testSecret})
+	cases := []struct {
+		name    string
+		headers map[string]string
+		want    Principal
+		wantErr bool
+	}{
+		{"api key with name", map[string]string{"X-API-Key": "viewer-key"}, Principal{Subject: "panel", Role: RoleViewer, Method: MethodAPIKey}, false},
+		{"api key without name", map[string]string{"X-API-Key": "admin-key"}, Principal{Subject: "api-key", Role: RoleAdmin, Method: MethodAPIKey}, false},
+		{"unknown api key", map[string]string{"X-API-Key": "other"}, Principal{}, true},
+		{"not a bearer token", map[string]string{"Authorization": "Basic dXNlcjpwYXNz"}, Principal{}, true},
+		{"no credentials", nil, Principal{}, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/zones", nil)
+			for name, value := range tc.headers {
+				r.Header.Set(name, value)
+			}
+			principal, err := a.Authenticate(r)
+			if tc.wantErr {
+				if !errors.Is(err, ErrUnauthenticated) {
+					t.Errorf("error = %v, want ErrUnauthenticated", err)
+				}
+				return
+			}
+			if err != nil || principal != tc.want {
+				t.Errorf("Authenticate = %+v, %v; want %+v", principal, err, tc.want)
+			}
+		})
+	}
+}
+
+func TestNewAuthenticatorRejectsInvalidConfig(t *testing.T) {
+	for name, cfg := range map[string]Config{
+		"no credentials":         {},
+		"unknown key role":       {APIKeys: "key:superuser"},
+		"key without role":       {APIKeys: "key"},
+		"unknown anonymous role": {AnonymousRole: "guest"},
+		"missing public key":     {RSAPublicKeyPath: filepath.Join(t.TempDir(), "missing.pem")},
+	} {
+		if _, err := NewAuthenticator(cfg); err == nil {
+			t.Errorf("%s: NewAuthenticator accepted %+v", name, cfg)
+		}
+	}
+}
+
+func TestRoleOrdering(t *testing.T) {
+	roles := []string{RoleViewer, RoleDispatcher, RoleAdmin}
+	for i, role := range roles {
+		for j, required := range roles {
+			if got, want := Allows(role, required), i >= j; got != want {
+				t.Errorf("Allows(%s, %s) = %v, want %v", role, required, got, want)
+			}
+		}
+	}
+	if Allows("", RoleViewer) || Allows("superuser", RoleViewer) {
+		t.Error("unknown roles must not be allowed")
+	}
+}
+
+func TestRequireMethods(t *testing.T) {
+	a := newTestAuthenticator(t, Config{APIKeys: "v:viewer,d:dispatcher,a:admin"})
+	var seen Principal
+	handler := a.RequireMethods(RoleViewer, RoleAdmin, func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = FromContext(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	cases := []struct {
+		key    string
+		method string
+		status int
+	}{
+		{"", http.MethodGet, http.StatusUnauthorized},
+		{"v", http.MethodGet, http.StatusNoContent},
+		{"v", http.MethodHead, http.StatusNoContent},
+		{"v", http.MethodPost, http.StatusForbidden},
+		{"d", http.MethodGet, http.StatusNoContent},
+		{"d", http.MethodPut, http.StatusForbidden},
+		{"d", http.MethodDelete, http.StatusForbidden},
+		{"a", http.MethodPost, http.StatusNoContent},
+		{"a", http.MethodDelete, http.StatusNoContent},
+	}
+	for _, tc := range cases {
+		seen = Principal{}
+		r := httptest.NewRequest(tc.method, "/api/snapshots", nil)
+		if tc.key != "" {
+			r.Header.Set("X-API-Key", tc.key)
+		}
+		w := httptest.NewRecorder()
+		handler(w, r)
+		if w.Code != tc.status {
+			t.Errorf("key %q %s: status %d, want %d", tc.key, tc.method, w.Code, tc.status)
+		}
+		if tc.status == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("key %q %s: 401 without WWW-Authenticate", tc.key, tc.method)
+		}
+		if tc.status == http.StatusNoContent && seen.Subject == "" {
+			t.Errorf("key %q %s: handler did not get the principal", tc.key, tc.method)
+		}
+	}
+}
+
+func TestAccessTokenOnlyOnStreams(t *testing.T) {
+	a := newTestAuthenticator(t, Config{APIKeys: "viewer-key:viewer", HMACSecret: testSecret})
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) }
+	stream := a.RequireStream(RoleViewer, ok)
+	require := a.Require(RoleViewer, ok)
+	jwt := token(t, "HS256", validClaims(), signHS256([]byte(testSecret)))
+
+	cases := []struct {
+		name    string
+		query   string
+		headers map[string]string
+		stream  int
+		require int
+	}{
+		{"api key in query", "access_token=viewer-key", nil, http.StatusNoContent, http.StatusUnauthorized},
+		{"jwt in query", "access_token=" + jwt, nil, http.StatusNoContent, http.StatusUnauthorized},
+		{"unknown token in query", "access_token=other", nil, http.StatusUnauthorized, http.StatusUnauthorized},
+		{"expired jwt in query", "access_token=" + token(t, "HS256", withClaim("exp", testNow.Add(-time.Hour).Unix()), signHS256([]byte(testSecret))),
+			nil, http.StatusUnauthorized, http.StatusUnauthorized},
+		// Una cabecera tiene prioridad sobre el parámetro
+		{"header wins over query", "access_token=viewer-key", map[string]string{"X-API-Key": "other"}, http.StatusUnauthorized, http.StatusUnauthorized},
+		{"header only", "", map[string]string{"X-API-Key": "viewer-key"}, http.StatusNoContent, http.StatusNoContent},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, handler := range []struct {
+				name   string
+				serve  http.HandlerFunc
+				status int
+			}{{"RequireStream", stream, tc.stream}, {"Require", require, tc.require}} {
+				r := httptest.NewRequest(http.MethodGet, "/api/events?"+tc.query, nil)
+				for name, value := range tc.headers {
+					r.Header.Set(name, value)
+				}
+				w := httptest.NewRecorder()
+				handler.serve(w, r)
+				if w.Code != handler.status {
+					t.Errorf("%s: status %d, want %d", handler.name, w.Code, handler.status)
+				}
+			}
+		})
+	}
+}
+
+func TestAccessTokenWithoutJWTKeys(t *testing.T) {
+	// Sin claves JWT un token desconocido se rechaza como clave, sin intentar verificarlo
+	a := newTestAuthenticator(t, Config{APIKeys: "viewer-key:viewer"})
+	r := httptest.NewRequest(http.MethodGet, "/api/events?access_token=other", nil)
+	if _, err := a.AuthenticateStream(r); err == nil || !strings.Contains(err.Error(), "unknown API key") {
+		t.Errorf("AuthenticateStream error = %v, want unknown API key", err)
+	}
+}
+
+func TestDisabledAndAnonymous(t *testing.T) {
+	disabled := newTestAuthenticator(t, Config{Disabled: true})
+	principal, err := disabled.Authenticate(httptest.NewRequest(http.MethodGet, "/", nil))
+	if err != nil || principal.Role != RoleAdmin {
+		t.Errorf("disabled: %+v, %v; want admin", principal, err)
+	}
+
+	anonymous := newTestAuthenticator(t, Config{AnonymousRole: RoleViewer, APIKeys: "admin-key:admin"})
+	principal, err = anonymous.Authenticate(httptest.NewRequest(http.MethodGet, "/", nil))
+	if err != nil || principal.Role != RoleViewer || principal.Method != MethodAnonymous {
+		t.Errorf("anonymous: %+v, %v; want viewer", principal, err)
+	}
+	// Una credencial inválida no cae al rol anónimo
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-API-Key", "other")
+	if _, err := anonymous.Authenticate(r); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("invalid key with anonymous role: %v, want ErrUnauthenticated", err)
+	}
+}
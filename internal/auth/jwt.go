@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Margen para diferencias de reloj al validar exp y nbf
+const clockSkew = time.Minute
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+}
+
+type jwtClaims struct {
+	Subject   string   `json:"sub"`
+	Role      string   `json:"role"`
+	Issuer    string   `json:"iss"`
+	Audience  audience `json:"aud"`
+	ExpiresAt *int64   `json:"exp"`
+	NotBefore *int64   `json:"nbf"`
+}
+
+// aud puede venir como cadena o como lista
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+// Lee una clave pública RSA en PEM (PKIX o PKCS#1) desde un archivo local
+func LoadRSAPublicKey(path string) (*rsa.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s does not contain a PEM block", path)
+	}
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse public key in %s: %w", path, err)
+	}
+	key, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an RSA public key", path)
+	}
+	return key, nil
+}
+
+// Verifica firma y vigencia del token. Solo acepta el algoritmo para el que hay clave configurada,
+// nunca 'none'
+func (a *Authenticator) verifyJWT(token string, now time.Time) (jwtClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return jwtClaims{}, errors.New("malformed token")
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return jwtClaims{}, fmt.Errorf("malformed token header: %w", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return jwtClaims{}, errors.New("malformed token signature")
+	}
+	signed := []byte(parts[0] + "." + parts[1])
+
+	switch header.Alg {
+	case "HS256":
+		if a.hmacSecret == nil {
+			return jwtClaims{}, errors.New("HS256 tokens are not accepted")
+		}
+		mac := hmac.New(sha256.New, a.hmacSecret)
+		mac.Write(signed)
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return jwtClaims{}, errors.New("invalid token signature")
+		}
+	case "RS256":
+		if a.rsaKey == nil {
+			return jwtClaims{}, errors.New("RS256 tokens are not accepted")
+		}
+		digest := sha256.Sum256(signed)
+		if err := rsa.VerifyPKCS1v15(a.rsaKey, crypto.SHA256, digest[:], signature); err != nil {
+			return jwtClaims{}, errors.New("invalid token signature")
+		}
+	default:
+		return jwtClaims{}, fmt.Errorf("unsupported token algorithm %q", header.Alg)
+	}
+
+	var claims jwtClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return jwtClaims{}, fmt.Errorf("malformed token claims: %w", err)
+	}
+	if claims.ExpiresAt == nil {
+		return jwtClaims{}, errors.New("token has no expiration")
+	}
+	if now.After(time.Unix(*claims.ExpiresAt, 0).Add(clockSkew)) {
+		return jwtClaims{}, errors.New("token expired")
+	}
+	if claims.NotBefore != nil && now.Add(clockSkew).Before(time.Unix(*claims.NotBefore, 0)) {
+		return jwtClaims{}, errors.New("token not valid yet")
+	}
+	if a.issuer != "" && claims.Issuer != a.issuer {
+		return jwtClaims{}, errors.New("unexpected token issuer")
+	}
+	if a.audience != "" && !contains(claims.Audience, a.audience) {
+		return jwtClaims{}, errors.New("unexpected token audience")
+	}
+	return claims, nil
+}
+
+func decodeSegment(segment string, target interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
+
+func contains(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
@@ -27,6 +27,28 @@ type Config struct {
 	Neo4jURI      string
 	Neo4jUser     string
 	Neo4jPassword string
+	MaxSpeedKmh   int
+	LogLevel      string // debug, info, warn o error
+	LogFormat     string // text o json
+	Tracing       string // exportador de trazas: none, stdout u otlp
+	// Segundos que se reutiliza el grafo de la red; 0 (por defecto) lo desactiva. Solo este
+	// proceso invalida la caché al escribir: con un valor mayor, los cambios hechos por otra
+	// réplica, un import por script o Neo4j Browser tardan hasta ese tiempo en verse en las rutas
+	GraphCacheTTL int
+	LocateMaxKm   int // km máximos al centroide más cercano fuera de los polígonos, 0 sin límite
+
+	// Reintentos de la conexión inicial a Neo4j; la espera (segundos) se duplica en cada intento
+	Neo4jConnectAttempts int
+	Neo4jConnectBackoff  int
+
+	// Autenticación: AUTH_DISABLED=true deja la API abierta (solo desarrollo)
+	AuthDisabled     bool
+	APIKeys          string // clave:rol[:nombre] separadas por comas
+	JWTSecret        string // HS256
+	JWTPublicKeyPath string // PEM para RS256
+	JWTIssuer        string
+	JWTAudience      string
+	AnonymousRole    string // rol sin credenciales, vacío las rechaza
 }
 
 func LoadConfig() *Config {
@@ -35,5 +57,22 @@ func LoadConfig() *Config {
 		Neo4jURI:      getEnv("NEO4J_URI", "bolt://localhost:7687"),
 		Neo4jUser:     getEnv("NEO4J_USER", "neo4j"),
 		Neo4jPassword: getEnv("NEO4J_PASSWORD", "12345678"),
+		MaxSpeedKmh:   getEnvAsInt("MAX_SPEED_KMH", 80),
+		LogLevel:      getEnv("LOG_LEVEL", "info"),
+		LogFormat:     getEnv("LOG_FORMAT", "text"),
+		Tracing:       getEnv("TRACING_EXPORTER", "none"),
+		GraphCacheTTL: getEnvAsInt("GRAPH_CACHE_TTL", 0),
+		LocateMaxKm:   getEnvAsInt("LOCATE_MAX_KM", 5),
+
+		Neo4jConnectAttempts: getEnvAsInt("NEO4J_CONNECT_ATTEMPTS", 10),
+		Neo4jConnectBackoff:  getEnvAsInt("NEO4J_CONNECT_BACKOFF", 1),
+
+		AuthDisabled:     getEnv("AUTH_DISABLED", "false") == "true",
+		APIKeys:          getEnv("API_KEYS", ""),
+		JWTSecret:        getEnv("JWT_HS256_SECRET", ""),
+		JWTPublicKeyPath: getEnv("JWT_RS256_PUBLIC_KEY", ""),
+		JWTIssuer:        getEnv("JWT_ISSUER", ""),
+		JWTAudience:      getEnv("JWT_AUDIENCE", ""),
+		AnonymousRole:    getEnv("AUTH_ANONYMOUS_ROLE", ""),
 	}
 }
@@ -0,0 +1,136 @@
+package database
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"neo4j_delivery/internal/metrics"
+	"neo4j_delivery/internal/tracing"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	queryDuration = metrics.NewHistogramVec("neo4j_query_duration_seconds",
+		"Duración de las transacciones y consultas a Neo4j por método del repositorio.", nil, "method", "mode")
+	queryErrors = metrics.NewCounterVec("neo4j_query_errors_total",
+		"Transacciones y consultas a Neo4j que terminaron con error, por método del repositorio.", "method")
+)
+
+// Las consultas más lentas que esto se registran como advertencia
+var SlowQueryThreshold = 500 * time.Millisecond
+
+// Resumen de una consulta Cypher para el log: una sola línea y como mucho 120 caracteres
+func summarize(cypher string) string {
+	summary := strings.Join(strings.Fields(cypher), " ")
+	if len(summary) > 120 {
+		summary = summary[:117] + "..."
+	}
+	return summary
+}
+
+func observe(method, mode string, start time.Time, err error) {
+	queryDuration.Observe(time.Since(start).Seconds(), method, mode)
+	if err != nil {
+		queryErrors.Inc(method)
+	}
+}
+
+func logQuery(ctx context.Context, kind, cypher string, start time.Time, err error) {
+	duration := time.Since(start)
+	level := slog.LevelDebug
+	if err != nil || duration > SlowQueryThreshold {
+		level = slog.LevelWarn
+	}
+	attrs := []any{"query", summarize(cypher), "duration_ms", float64(duration.Microseconds()) / 1000}
+	if err != nil {
+		attrs = append(attrs, "err", err)
+	}
+	slog.Log(ctx, level, kind, attrs...)
+}
+
+// Driver que mide cada llamada a Neo4j. Delegamos todo lo demás al driver original
+type timedDriver struct {
+	neo4j.Driver
+}
+
+func (d timedDriver) NewSession(config neo4j.SessionConfig) neo4j.Session {
+	return d.NewSessionContext(context.Background(), "unknown", config)
+}
+
+// Sesión asociada a una petición: sus transacciones quedan como spans hijos de ctx y
+// sus registros llevan el request_id. method nombra el span y la serie de las métricas
+func (d timedDriver) NewSessionContext(ctx context.Context, method string, config neo4j.SessionConfig) neo4j.Session {
+	return timedSession{Session: d.Driver.NewSession(config), ctx: ctx, method: method}
+}
+
+type timedSession struct {
+	neo4j.Session
+	ctx    context.Context
+	method string
+}
+
+func (s timedSession) start(mode string) (context.Context, trace.Span) {
+	return tracing.Start(s.ctx, s.method,
+		attribute.String("db.system", "neo4j"),
+		attribute.String("db.operation", mode),
+	)
+}
+
+func finish(span trace.Span, cypher string, err error) {
+	span.SetAttributes(attribute.String("db.statement", summarize(cypher)))
+	tracing.RecordError(span, err)
+	span.End()
+}
+
+func (s timedSession) wrap(mode string, work neo4j.TransactionWork) neo4j.TransactionWork {
+	return func(tx neo4j.Transaction) (any, error) {
+		ctx, span := s.start(mode)
+		timed := &timedTransaction{Transaction: tx, ctx: ctx}
+		start := time.Now()
+		result, err := work(timed)
+		logQuery(ctx, "neo4j "+mode+" transaction", timed.first, start, err)
+		observe(s.method, mode, start, err)
+		finish(span, timed.first, err)
+		return result, err
+	}
+}
+
+func (s timedSession) ReadTransaction(work neo4j.TransactionWork, configurers ...func(*neo4j.TransactionConfig)) (any, error) {
+	return s.Session.ReadTransaction(s.wrap("read", work), configurers...)
+}
+
+func (s timedSession) WriteTransaction(work neo4j.TransactionWork, configurers ...func(*neo4j.TransactionConfig)) (any, error) {
+	return s.Session.WriteTransaction(s.wrap("write", work), configurers...)
+}
+
+func (s timedSession) Run(cypher string, params map[string]any, configurers ...func(*neo4j.TransactionConfig)) (neo4j.Result, error) {
+	ctx, span := s.start("auto")
+	start := time.Now()
+	result, err := s.Session.Run(cypher, params, configurers...)
+	logQuery(ctx, "neo4j query", cypher, start, err)
+	observe(s.method, "auto", start, err)
+	finish(span, cypher, err)
+	return result, err
+}
+
+// Mide cada sentencia de la transacción; first identifica la transacción en el log
+type timedTransaction struct {
+	neo4j.Transaction
+	ctx   context.Context
+	first string
+}
+
+func (t *timedTransaction) Run(cypher string, params map[string]any) (neo4j.Result, error) {
+	if t.first == "" {
+		t.first = cypher
+	}
+	start := time.Now()
+	result, err := t.Transaction.Run(cypher, params)
+	logQuery(t.ctx, "neo4j query", cypher, start, err)
+	return result, err
+}
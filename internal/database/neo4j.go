@@ -1,12 +1,18 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"os"
+	"log/slog"
+	"time"
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 )
 
+// Espera máxima entre dos intentos de conexión
+const MaxConnectBackoff = 30 * time.Second
+
 type Neo4jDatabase struct {
 	Driver neo4j.Driver
 }
@@ -23,7 +29,62 @@ func NewNeo4jDatabase(uri, username, password string) (*Neo4jDatabase, error) {
 		return nil, fmt.Errorf("failed to verify connection: %w", err)
 	}
 
-	return &Neo4jDatabase{Driver: driver}, nil
+	// Todas las consultas de los repositorios quedan medidas en el log
+	return &Neo4jDatabase{Driver: timedDriver{driver}}, nil
+}
+
+// Igual que NewNeo4jDatabase, pero si Neo4j todavía no responde reintenta hasta attempts veces,
+// duplicando la espera desde backoff hasta MaxConnectBackoff
+func ConnectWithRetry(uri, username, password string, attempts int, backoff time.Duration) (*Neo4jDatabase, error) {
+	driver, err := neo4j.NewDriver(uri, neo4j.BasicAuth(username, password, ""))
+	if err != nil {
+		return nil, fmt.Errorf("could not create neo4j driver: %w", err)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = driver.VerifyConnectivity()
+		if err == nil {
+			return &Neo4jDatabase{Driver: timedDriver{driver}}, nil
+		}
+		if attempt >= attempts {
+			driver.Close()
+			return nil, fmt.Errorf("failed to verify connection after %d attempts: %w", attempt, err)
+		}
+		slog.Warn("Neo4j not available, retrying", "attempt", attempt, "retry_in", backoff.String(), "err", err)
+		time.Sleep(backoff)
+		backoff = min(backoff*2, MaxConnectBackoff)
+	}
+}
+
+// Verifica que Neo4j siga respondiendo, para la comprobación de /readyz
+func (db *Neo4jDatabase) Ping() error {
+	return db.Driver.VerifyConnectivity()
+}
+
+// Indica si existe la restricción con ese nombre, para comprobar que scripts/schema.cypher se aplicó
+func (db *Neo4jDatabase) HasConstraint(name string) (bool, error) {
+	session := db.newSession("Neo4jDatabase.HasConstraint")
+	defer session.Close()
+
+	// SHOW no se admite dentro de una transacción explícita
+	result, err := session.Run("SHOW CONSTRAINTS YIELD name WHERE name = $name RETURN count(*) > 0 AS found", map[string]interface{}{"name": name})
+	if err != nil {
+		return false, err
+	}
+	record, err := result.Single()
+	if err != nil {
+		return false, err
+	}
+	found, _ := record.Values[0].(bool)
+	return found, nil
+}
+
+// Sesión que se mide con el nombre de method, igual que las de los repositorios
+func (db *Neo4jDatabase) newSession(method string) neo4j.Session {
+	if timed, ok := db.Driver.(timedDriver); ok {
+		return timed.NewSessionContext(context.Background(), method, neo4j.SessionConfig{})
+	}
+	return db.Driver.NewSession(neo4j.SessionConfig{})
 }
 
 func (db *Neo4jDatabase) Close() error {
@@ -32,19 +93,45 @@ func (db *Neo4jDatabase) Close() error {
 
 // ExecuteCypherFile mejorado para manejar transacciones y múltiples sentencias
 func (db *Neo4jDatabase) ExecuteCypherFile(filePath string) error {
-	session := db.Driver.NewSession(neo4j.SessionConfig{})
+	_, err := db.executeCypherFile(filePath, false)
+	return err
+}
+
+// SeedCypherFile ejecuta el archivo solo si la base no tiene zonas, para no pisar la red en
+// cada arranque. Retorna false si la base ya tenía datos y no se ejecutó nada
+func (db *Neo4jDatabase) SeedCypherFile(filePath string) (bool, error) {
+	return db.executeCypherFile(filePath, true)
+}
+
+func (db *Neo4jDatabase) executeCypherFile(filePath string, onlyIfEmpty bool) (bool, error) {
+	session := db.newSession("Neo4jDatabase.ExecuteCypherFile")
 	defer session.Close()
 
 	// Leer el archivo .cypher
 	cypher, err := os.ReadFile(filePath)
 	if err != nil {
-		return fmt.Errorf("error reading cypher file: %w", err)
+		return false, fmt.Errorf("error reading cypher file: %w", err)
 	}
 
 	// Dividir en sentencias individuales (separadas por ;)
 	statements := strings.Split(string(cypher), ";")
 
-	_, err = session.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+	executed, err := session.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		// La comprobación va en la misma transacción que la carga
+		if onlyIfEmpty {
+			result, err := tx.Run("MATCH (z:Zona) RETURN count(z) > 0 AS seeded", nil)
+			if err != nil {
+				return false, err
+			}
+			record, err := result.Single()
+			if err != nil {
+				return false, err
+			}
+			if seeded, _ := record.Values[0].(bool); seeded {
+				return false, nil
+			}
+		}
+
 		for _, stmt := range statements {
 			// Eliminar espacios en blanco y saltos de línea
 			stmt = strings.TrimSpace(stmt)
@@ -55,11 +142,13 @@ func (db *Neo4jDatabase) ExecuteCypherFile(filePath string) error {
 			// Ejecutar cada sentencia
 			_, err := tx.Run(stmt, nil)
 			if err != nil {
-				return nil, fmt.Errorf("error executing statement: %q, error: %w", stmt, err)
+				return false, fmt.Errorf("error executing statement: %q, error: %w", stmt, err)
 			}
 		}
-		return nil, nil
+		return true, nil
 	})
-
-	return err
+	if err != nil {
+		return false, err
+	}
+	return executed.(bool), nil
 }
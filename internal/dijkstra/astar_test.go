@@ -0,0 +1,136 @@
+package dijkstra
+
+import (
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"neo4j_delivery/internal/geo"
+	"neo4j_delivery/internal/models"
+)
+
+var (
+	seedZone     = regexp.MustCompile(`\((\w+):[\w:]*Zona \{nombre: '([^']+)'[^}]*latitud: ([-\d.]+), longitud: ([-\d.]+)`)
+	seedMatch    = regexp.MustCompile(`\((\w+):\w+ \{nombre: '([^']+)'\}\)`)
+	seedCreate   = regexp.MustCompile(`CREATE \((\w+)\)-\[:CONECTA \{tiempo_minutos: ([\d.]+)[^}]*\}\]->\((\w+)\)`)
+	seedMerge    = regexp.MustCompile(`MERGE \((\w+)\)-\[\w*:CONECTA\]->\((\w+)\)`)
+	seedMergeSet = regexp.MustCompile(`tiempo_minutos = ([\d.]+)`)
+)
+
+// Lee el grafo y las coordenadas de scripts/data.cypher, con todos los tramos accesibles como en la semilla
+func seedGraph(t *testing.T) (models.Graph, map[string]geo.Point) {
+	t.Helper()
+	script, err := os.ReadFile("../../scripts/data.cypher")
+	if err != nil {
+		t.Fatal(err)
+	}
+	graph := models.Graph{}
+	coordinates := map[string]geo.Point{}
+	addEdge := func(from, to, minutes string) {
+		cost, err := strconv.ParseFloat(minutes, 64)
+		if err != nil {
+			t.Fatalf("invalid tiempo_minutos %q: %v", minutes, err)
+		}
+		graph[from] = append(graph[from], models.Edge{Item: to, Cost: cost, Accesible: true})
+	}
+
+	for _, statement := range strings.Split(string(script), ";") {
+		names := map[string]string{}
+		for _, m := range seedZone.FindAllStringSubmatch(statement, -1) {
+			lat, _ := strconv.ParseFloat(m[3], 64)
+			lng, _ := strconv.ParseFloat(m[4], 64)
+			coordinates[m[2]] = geo.Point{Lat: lat, Lng: lng}
+			if _, exists := graph[m[2]]; !exists {
+				graph[m[2]] = []models.Edge{}
+			}
+		}
+		for _, m := range seedMatch.FindAllStringSubmatch(statement, -1) {
+			names[m[1]] = m[2]
+		}
+		for _, m := range seedCreate.FindAllStringSubmatch(statement, -1) {
+			addEdge(names[m[1]], names[m[3]], m[2])
+		}
+		if m := seedMerge.FindStringSubmatch(statement); m != nil {
+			set := seedMergeSet.FindStringSubmatch(statement)
+			if set == nil {
+				t.Fatalf("MERGE of a CONECTA without tiempo_minutos: %s", statement)
+			}
+			addEdge(names[m[1]], names[m[2]], set[1])
+		}
+	}
+	if len(coordinates) == 0 || len(graph) != len(coordinates) {
+		t.Fatalf("seed parsed %d zones with coordinates and %d graph nodes", len(coordinates), len(graph))
+	}
+	return graph, coordinates
+}
+
+func TestAStarMatchesDijkstraOnSeed(t *testing.T) {
+	graph, coordinates := seedGraph(t)
+
+	// Sin coordenadas en una zona intermedia la heurística debe caer a 0 y seguir dando el óptimo
+	partial := map[string]geo.Point{}
+	for zone, point := range coordinates {
+		if zone != "Puerto Ordaz" {
+			partial[zone] = point
+		}
+	}
+
+	cases := []struct {
+		name        string
+		start, end  string
+		coordinates map[string]geo.Point
+	}{
+		{"center to commercial", "Centro Principal", "AltaVista", coordinates},
+		{"center to residential", "Centro Principal", "Villa Asia", coordinates},
+		{"across the river", "Centro Principal", "San Félix", coordinates},
+		{"residential loop", "Castillito", "Los Olivos", coordinates},
+		{"secondary center", "Centro Secundario", "Paseo Caroni", coordinates},
+		{"one-way back", "AltaVista", "Castillito", coordinates},
+		{"zone without coordinates", "Centro Principal", "Los Olivos", partial},
+		{"goal without coordinates", "AltaVista", "Puerto Ordaz", partial},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, want, err := Travel(Dijkstra(graph, tc.start), tc.start, tc.end)
+			if err != nil {
+				t.Fatalf("Dijkstra: %v", err)
+			}
+			heuristic := TimeHeuristic(graph, tc.coordinates, 60, tc.end)
+			path, got, err := AStar(graph, tc.start, tc.end, heuristic)
+			if err != nil {
+				t.Fatalf("AStar: %v", err)
+			}
+			if math.Abs(got-want) > 1e-9 {
+				t.Errorf("AStar cost %v (path %v), Dijkstra cost %v", got, path, want)
+			}
+		})
+	}
+}
+
+func TestTimeHeuristicWithoutCoordinatesIsZero(t *testing.T) {
+	// La ruta rápida pasa por dos zonas sin coordenadas y recorre la distancia a A mucho más rápido
+	// que la única arista medida, S -> G. Con una cota de velocidad solo sobre aristas medidas, h(A)
+	// sobreestimaría y A* se quedaría con el tramo directo de 10 minutos
+	graph := models.Graph{
+		"S":  {{Item: "X1", Cost: 1, Accesible: true}, {Item: "G", Cost: 10, Accesible: true}},
+		"X1": {{Item: "A", Cost: 1, Accesible: true}},
+		"A":  {{Item: "X2", Cost: 1, Accesible: true}},
+		"X2": {{Item: "G", Cost: 1, Accesible: true}},
+		"G":  {},
+	}
+	coordinates := map[string]geo.Point{
+		"S": {Lat: 0, Lng: 0},
+		"G": {Lat: 0, Lng: 0.001},
+		"A": {Lat: 1, Lng: 0},
+	}
+	heuristic := TimeHeuristic(graph, coordinates, 60, "G")
+	if h := heuristic("A"); h != 0 {
+		t.Errorf("heuristic(A) = %v, want 0 when some zones have no coordinates", h)
+	}
+	if _, cost, err := AStar(graph, "S", "G", heuristic); err != nil || cost != 4 {
+		t.Errorf("AStar cost = %v, %v; want 4", cost, err)
+	}
+}
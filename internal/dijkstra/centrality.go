@@ -0,0 +1,125 @@
+package dijkstra
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+
+	"neo4j_delivery/internal/models"
+)
+
+// Tolerancia para considerar dos caminos ponderados como igual de cortos
+const costEpsilon = 1e-9
+
+type queueItem struct {
+	node string
+	cost float64
+}
+
+type priorityQueue []queueItem
+
+func (pq priorityQueue) Len() int            { return len(pq) }
+func (pq priorityQueue) Less(i, j int) bool  { return pq[i].cost < pq[j].cost }
+func (pq priorityQueue) Swap(i, j int)       { pq[i], pq[j] = pq[j], pq[i] }
+func (pq *priorityQueue) Push(x interface{}) { *pq = append(*pq, x.(queueItem)) }
+func (pq *priorityQueue) Pop() interface{} {
+	old := *pq
+	item := old[len(old)-1]
+	*pq = old[:len(old)-1]
+	return item
+}
+
+// Calcula la centralidad de intermediación (Brandes, con pesos) de nodos y aristas,
+// y la centralidad de cercanía de cada nodo. Solo se usan las aristas accesibles.
+// La cercanía usa la corrección de Wasserman-Faust para grafos no fuertemente conexos
+func Centrality(graph models.Graph) ([]models.ZoneCentrality, []models.SegmentCentrality) {
+	nodes := GetNodes(graph)
+	sort.Strings(nodes)
+
+	nodeBetweenness := make(map[string]float64)
+	edgeBetweenness := make(map[models.SegmentRef]float64)
+	closeness := make(map[string]float64)
+
+	for _, source := range nodes {
+		// Fase 1: Dijkstra desde source contando caminos mínimos
+		stack := []string{}
+		predecessors := make(map[string][]string)
+		sigma := map[string]float64{source: 1}
+		dist := map[string]float64{source: 0}
+		settled := make(map[string]bool)
+
+		pq := &priorityQueue{{node: source, cost: 0}}
+		for pq.Len() > 0 {
+			item := heap.Pop(pq).(queueItem)
+			if settled[item.node] {
+				continue
+			}
+			settled[item.node] = true
+			stack = append(stack, item.node)
+
+			for _, neighbor := range graph[item.node] {
+				if !neighbor.Accesible {
+					continue
+				}
+				newCost := dist[item.node] + neighbor.Cost
+				current, seen := dist[neighbor.Item]
+				if !seen || newCost < current-costEpsilon {
+					dist[neighbor.Item] = newCost
+					sigma[neighbor.Item] = sigma[item.node]
+					predecessors[neighbor.Item] = []string{item.node}
+					heap.Push(pq, queueItem{node: neighbor.Item, cost: newCost})
+				} else if math.Abs(newCost-current) <= costEpsilon {
+					sigma[neighbor.Item] += sigma[item.node]
+					predecessors[neighbor.Item] = append(predecessors[neighbor.Item], item.node)
+				}
+			}
+		}
+
+		// Fase 2: acumulación de dependencias en orden inverso de distancia
+		delta := make(map[string]float64)
+		for i := len(stack) - 1; i >= 0; i-- {
+			w := stack[i]
+			for _, v := range predecessors[w] {
+				contribution := sigma[v] / sigma[w] * (1 + delta[w])
+				edgeBetweenness[models.SegmentRef{Source: v, Target: w}] += contribution
+				delta[v] += contribution
+			}
+			if w != source {
+				nodeBetweenness[w] += delta[w]
+			}
+		}
+
+		totalDistance := 0.0
+		for _, node := range stack {
+			totalDistance += dist[node]
+		}
+		reached := float64(len(stack) - 1)
+		if reached > 0 && totalDistance > 0 {
+			closeness[source] = (reached / totalDistance) * (reached / float64(len(nodes)-1))
+		}
+	}
+
+	zones := make([]models.ZoneCentrality, 0, len(nodes))
+	for _, node := range nodes {
+		zones = append(zones, models.ZoneCentrality{
+			Zone:        node,
+			Betweenness: nodeBetweenness[node],
+			Closeness:   closeness[node],
+		})
+	}
+
+	segments := []models.SegmentCentrality{}
+	for _, source := range nodes {
+		for _, edge := range graph[source] {
+			if !edge.Accesible {
+				continue
+			}
+			segments = append(segments, models.SegmentCentrality{
+				Source:      source,
+				Target:      edge.Item,
+				Betweenness: edgeBetweenness[models.SegmentRef{Source: source, Target: edge.Item}],
+			})
+		}
+	}
+	return zones, segments
+}
@@ -0,0 +1,106 @@
+package dijkstra
+
+import (
+	"sort"
+
+	"neo4j_delivery/internal/models"
+)
+
+// Conjunto de nodos alcanzables desde los orígenes por aristas accesibles.
+// Se puede excluir un nodo completo (blockedNode) o un tramo dirigido (blockedFrom -> blockedTo)
+func reachableFrom(graph models.Graph, sources []string, blockedNode, blockedFrom, blockedTo string) map[string]bool {
+	visited := make(map[string]bool)
+	queue := []string{}
+
+	for _, source := range sources {
+		if source == blockedNode || visited[source] {
+			continue
+		}
+		visited[source] = true
+		queue = append(queue, source)
+	}
+
+	for len(queue) > 0 {
+		currentNode := queue[0]
+		queue = queue[1:]
+
+		for _, neighbor := range graph[currentNode] {
+			if !neighbor.Accesible || visited[neighbor.Item] || neighbor.Item == blockedNode {
+				continue
+			}
+			if currentNode == blockedFrom && neighbor.Item == blockedTo {
+				continue
+			}
+			visited[neighbor.Item] = true
+			queue = append(queue, neighbor.Item)
+		}
+	}
+	return visited
+}
+
+// Nodos que eran alcanzables en base y dejan de serlo en after, sin contar el nodo excluido
+func lostNodes(base, after map[string]bool, excluded string) []string {
+	lost := []string{}
+	for node := range base {
+		if node != excluded && !after[node] {
+			lost = append(lost, node)
+		}
+	}
+	sort.Strings(lost)
+	return lost
+}
+
+// Encuentra los tramos CONECTA que, al cerrarse, dejan zonas sin camino desde los orígenes.
+// Es la versión dirigida de los puentes: se prueba cada arista accesible por separado
+func FindCriticalSegments(graph models.Graph, sources []string) []models.CriticalSegment {
+	base := reachableFrom(graph, sources, "", "", "")
+	segments := []models.CriticalSegment{}
+
+	for node, edges := range graph {
+		if !base[node] {
+			continue
+		}
+		for _, edge := range edges {
+			if !edge.Accesible {
+				continue
+			}
+			after := reachableFrom(graph, sources, "", node, edge.Item)
+			lost := lostNodes(base, after, "")
+			if len(lost) > 0 {
+				segments = append(segments, models.CriticalSegment{
+					Source:      node,
+					Target:      edge.Item,
+					CutOffZones: lost,
+				})
+			}
+		}
+	}
+	return segments
+}
+
+// Encuentra las zonas que, al quedar cerradas, dejan otras zonas sin camino desde los orígenes.
+// Es la versión dirigida de los puntos de articulación
+func FindCriticalZones(graph models.Graph, sources []string) []models.CriticalZone {
+	base := reachableFrom(graph, sources, "", "", "")
+	zones := []models.CriticalZone{}
+
+	for _, node := range GetNodes(graph) {
+		if !base[node] {
+			continue
+		}
+		after := reachableFrom(graph, sources, node, "", "")
+		lost := lostNodes(base, after, node)
+		if len(lost) > 0 {
+			zones = append(zones, models.CriticalZone{
+				Zone:        node,
+				CutOffZones: lost,
+			})
+		}
+	}
+	return zones
+}
+
+// Zonas alcanzables desde alguna de las fuentes usando solo tramos accesibles
+func ReachableFrom(graph models.Graph, sources []string) map[string]bool {
+	return reachableFrom(graph, sources, "", "", "")
+}
@@ -2,10 +2,18 @@ package dijkstra
 
 import (
 	"fmt"
+	"log/slog"
 	"math"
+	"neo4j_delivery/internal/metrics"
 	"neo4j_delivery/internal/models"
+	"time"
 )
 
+// Cada búsqueda por separado; las rutas que recorren todo el grafo suman varias corridas
+var runDuration = metrics.NewHistogramVec("dijkstra_duration_seconds",
+	"Duración de cada búsqueda de caminos mínimos por algoritmo.",
+	[]float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1}, "algorithm")
+
 func GetNodes(graph models.Graph) []string {
 	var nodes []string
 	seen := make(map[string]bool)
@@ -63,8 +71,8 @@ func findMinCostNode(costs map[string]models.Edge, unvisitedNodes map[string]boo
 }
 
 func Dijkstra(graph models.Graph, start string) map[string]models.Edge {
+	defer observeRun("dijkstra", time.Now())
 	table := InitCosts(graph, start)
-	fmt.Println("Initial costs:", table)
 
 	unvisitedNodes := make(map[string]bool)
 	for _, node := range GetNodes(graph) {
@@ -87,13 +95,16 @@ func Dijkstra(graph models.Graph, start string) map[string]models.Edge {
 				table[neighbor.Item] = models.Edge{currentNode, true, newCost}
 			}
 		}
-		fmt.Println("Current table after processing", currentNode, ":", table)
 	}
 
-	fmt.Println("\nFinal costs table:", table)
+	slog.Debug("dijkstra finished", "start", start, "nodes", len(table))
 	return table
 }
 
+func observeRun(algorithm string, start time.Time) {
+	runDuration.Observe(time.Since(start).Seconds(), algorithm)
+}
+
 // Retorna un arreglo de strings con los en orden a recorrer para llegar al destino indicado
 func Travel(table map[string]models.Edge, start string, end string) ([]string, float64, error) {
 	var path []string
@@ -132,7 +143,7 @@ func Travel(table map[string]models.Edge, start string, end string) ([]string, f
 
 func RemoveElementByIndex[T any](slice []T, index int) ([]T, bool) {
 	if index < 0 || index >= len(slice) {
-		fmt.Printf("Error: Index %d is out of bounds for slice of length %d\n", index, len(slice))
+		slog.Warn("index out of bounds", "index", index, "length", len(slice))
 		return slice, false
 	}
 
@@ -0,0 +1,71 @@
+package dijkstra
+
+import (
+	"math"
+
+	"neo4j_delivery/internal/models"
+)
+
+// Copia profunda del grafo, para poder modificarlo sin afectar al original
+func CopyGraph(graph models.Graph) models.Graph {
+	copied := make(models.Graph, len(graph))
+	for node, edges := range graph {
+		copied[node] = append([]models.Edge{}, edges...)
+	}
+	return copied
+}
+
+// Retorna un grafo con solo las aristas accesibles, conservando todos los nodos
+func AccessibleSubgraph(graph models.Graph) models.Graph {
+	subgraph := make(models.Graph, len(graph))
+	for node, edges := range graph {
+		subgraph[node] = []models.Edge{}
+		for _, edge := range edges {
+			if edge.Accesible {
+				subgraph[node] = append(subgraph[node], edge)
+			}
+		}
+	}
+	return subgraph
+}
+
+// Costo mínimo para llegar a cada nodo desde cualquiera de los orígenes.
+// Los nodos inalcanzables quedan con costo infinito
+func MinCostFromSources(graph models.Graph, sources []string) map[string]float64 {
+	costs := make(map[string]float64)
+	for _, node := range GetNodes(graph) {
+		costs[node] = math.Inf(1)
+	}
+
+	for _, source := range sources {
+		if _, exists := costs[source]; !exists {
+			continue
+		}
+		table := Dijkstra(graph, source)
+		for node, entry := range table {
+			if entry.Cost < costs[node] {
+				costs[node] = entry.Cost
+			}
+		}
+	}
+	return costs
+}
+
+// Nodos a lo sumo a hops aristas de start siguiendo la dirección de los tramos, incluido start
+func WithinHops(graph models.Graph, start string, hops int) map[string]bool {
+	visited := map[string]bool{start: true}
+	frontier := []string{start}
+	for depth := 0; depth < hops && len(frontier) > 0; depth++ {
+		next := []string{}
+		for _, node := range frontier {
+			for _, edge := range graph[node] {
+				if !visited[edge.Item] {
+					visited[edge.Item] = true
+					next = append(next, edge.Item)
+				}
+			}
+		}
+		frontier = next
+	}
+	return visited
+}
@@ -0,0 +1,142 @@
+package dijkstra
+
+import (
+	"sort"
+
+	"neo4j_delivery/internal/models"
+)
+
+// Arista de la red residual. rev es el índice de la arista inversa en la lista del destino
+type flowEdge struct {
+	to       int
+	rev      int
+	capacity int
+	original int // índice de la conexión original, -1 para aristas inversas y auxiliares
+}
+
+type flowNetwork struct {
+	adj   [][]flowEdge
+	level []int
+	iter  []int
+}
+
+func (f *flowNetwork) addEdge(from, to, capacity, original int) {
+	f.adj[from] = append(f.adj[from], flowEdge{to: to, rev: len(f.adj[to]), capacity: capacity, original: original})
+	f.adj[to] = append(f.adj[to], flowEdge{to: from, rev: len(f.adj[from]) - 1, capacity: 0, original: -1})
+}
+
+// BFS que arma el grafo de niveles; retorna false si el sumidero ya no es alcanzable
+func (f *flowNetwork) buildLevels(source, sink int) bool {
+	for i := range f.level {
+		f.level[i] = -1
+	}
+	f.level[source] = 0
+	queue := []int{source}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, edge := range f.adj[current] {
+			if edge.capacity > 0 && f.level[edge.to] < 0 {
+				f.level[edge.to] = f.level[current] + 1
+				queue = append(queue, edge.to)
+			}
+		}
+	}
+	return f.level[sink] >= 0
+}
+
+// DFS que empuja un flujo bloqueante por el grafo de niveles
+func (f *flowNetwork) push(node, sink, limit int) int {
+	if node == sink {
+		return limit
+	}
+	for ; f.iter[node] < len(f.adj[node]); f.iter[node]++ {
+		edge := &f.adj[node][f.iter[node]]
+		if edge.capacity <= 0 || f.level[edge.to] != f.level[node]+1 {
+			continue
+		}
+		pushed := f.push(edge.to, sink, min(limit, edge.capacity))
+		if pushed > 0 {
+			edge.capacity -= pushed
+			f.adj[edge.to][edge.rev].capacity += pushed
+			return pushed
+		}
+	}
+	return 0
+}
+
+// Calcula el flujo máximo (Dinic) desde los orígenes hasta el destino usando la capacidad de cada tramo.
+// Retorna el flujo total y los tramos que forman el corte mínimo (el cuello de botella).
+// Solo se consideran los tramos accesibles
+func MaxFlow(connections []models.Connection, sources []string, sink string) (int, []models.Connection) {
+	ids := make(map[string]int)
+	nodeID := func(name string) int {
+		if id, exists := ids[name]; exists {
+			return id
+		}
+		ids[name] = len(ids)
+		return ids[name]
+	}
+	for _, connection := range connections {
+		nodeID(connection.Source)
+		nodeID(connection.Target)
+	}
+	sinkID, sinkExists := ids[sink]
+	if !sinkExists {
+		return 0, []models.Connection{}
+	}
+
+	// Nodo auxiliar que alimenta a todos los orígenes con capacidad ilimitada
+	superSource := len(ids)
+	network := &flowNetwork{adj: make([][]flowEdge, len(ids)+1)}
+	for i, connection := range connections {
+		if connection.Accesible && connection.Capacidad > 0 {
+			network.addEdge(ids[connection.Source], ids[connection.Target], connection.Capacidad, i)
+		}
+	}
+	unlimited := 0
+	for _, connection := range connections {
+		unlimited += connection.Capacidad
+	}
+	for _, source := range sources {
+		if id, exists := ids[source]; exists && id != sinkID {
+			network.addEdge(superSource, id, unlimited+1, -1)
+		}
+	}
+
+	network.level = make([]int, len(network.adj))
+	network.iter = make([]int, len(network.adj))
+	total := 0
+	for network.buildLevels(superSource, sinkID) {
+		for i := range network.iter {
+			network.iter[i] = 0
+		}
+		for {
+			pushed := network.push(superSource, sinkID, unlimited+1)
+			if pushed == 0 {
+				break
+			}
+			total += pushed
+		}
+	}
+
+	// Tras el último BFS, level >= 0 marca el lado del origen en el corte mínimo
+	cut := []models.Connection{}
+	for node := range network.adj {
+		if network.level[node] < 0 {
+			continue
+		}
+		for _, edge := range network.adj[node] {
+			if edge.original >= 0 && network.level[edge.to] < 0 {
+				cut = append(cut, connections[edge.original])
+			}
+		}
+	}
+	sort.Slice(cut, func(i, j int) bool {
+		if cut[i].Source != cut[j].Source {
+			return cut[i].Source < cut[j].Source
+		}
+		return cut[i].Target < cut[j].Target
+	})
+	return total, cut
+}
@@ -0,0 +1,139 @@
+package dijkstra
+
+import (
+	"sort"
+
+	"neo4j_delivery/internal/models"
+)
+
+// Calcula las componentes fuertemente conexas del grafo (Tarjan).
+// Solo se consideran las aristas accesibles, igual que en FindInaccessibleNodes
+func StronglyConnectedComponents(graph models.Graph) [][]string {
+	nodes := GetNodes(graph)
+	sort.Strings(nodes)
+
+	index := 0
+	indices := make(map[string]int)
+	lowlink := make(map[string]int)
+	onStack := make(map[string]bool)
+	stack := []string{}
+	components := [][]string{}
+
+	var strongConnect func(node string)
+	strongConnect = func(node string) {
+		indices[node] = index
+		lowlink[node] = index
+		index++
+		stack = append(stack, node)
+		onStack[node] = true
+
+		for _, neighbor := range graph[node] {
+			if !neighbor.Accesible {
+				continue
+			}
+			if _, visited := indices[neighbor.Item]; !visited {
+				strongConnect(neighbor.Item)
+				lowlink[node] = min(lowlink[node], lowlink[neighbor.Item])
+			} else if onStack[neighbor.Item] {
+				lowlink[node] = min(lowlink[node], indices[neighbor.Item])
+			}
+		}
+
+		// El nodo es raíz de una componente: se desapila hasta llegar a él
+		if lowlink[node] == indices[node] {
+			component := []string{}
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[top] = false
+				component = append(component, top)
+				if top == node {
+					break
+				}
+			}
+			sort.Strings(component)
+			components = append(components, component)
+		}
+	}
+
+	for _, node := range nodes {
+		if _, visited := indices[node]; !visited {
+			strongConnect(node)
+		}
+	}
+	return components
+}
+
+// Retorna las componentes a las que se puede entrar pero de las que no se puede salir.
+// Un conductor que llegue a una de ellas queda atrapado por las vías de un solo sentido
+func FindOneWayTraps(graph models.Graph, components [][]string) [][]string {
+	componentOf := make(map[string]int)
+	for i, component := range components {
+		for _, node := range component {
+			componentOf[node] = i
+		}
+	}
+
+	hasIncoming := make([]bool, len(components))
+	hasOutgoing := make([]bool, len(components))
+	for node, edges := range graph {
+		for _, edge := range edges {
+			if !edge.Accesible || componentOf[node] == componentOf[edge.Item] {
+				continue
+			}
+			hasOutgoing[componentOf[node]] = true
+			hasIncoming[componentOf[edge.Item]] = true
+		}
+	}
+
+	traps := [][]string{}
+	for i, component := range components {
+		if hasIncoming[i] && !hasOutgoing[i] {
+			traps = append(traps, component)
+		}
+	}
+	return traps
+}
+
+// Retorna los nodos desde los cuales no existe camino accesible hacia ninguno de los destinos.
+// Se hace un BFS sobre el grafo invertido partiendo de todos los destinos a la vez
+func FindNodesWithoutReturn(graph models.Graph, targets []string) []string {
+	reversed := make(map[string][]string)
+	for node, edges := range graph {
+		for _, edge := range edges {
+			if edge.Accesible {
+				reversed[edge.Item] = append(reversed[edge.Item], node)
+			}
+		}
+	}
+
+	visited := make(map[string]bool)
+	queue := []string{}
+	for _, target := range targets {
+		if !visited[target] {
+			visited[target] = true
+			queue = append(queue, target)
+		}
+	}
+
+	for len(queue) > 0 {
+		currentNode := queue[0]
+		queue = queue[1:]
+
+		for _, predecessor := range reversed[currentNode] {
+			if !visited[predecessor] {
+				visited[predecessor] = true
+				queue = append(queue, predecessor)
+			}
+		}
+	}
+
+	withoutReturn := []string{}
+	for _, node := range GetNodes(graph) {
+		if !visited[node] {
+			withoutReturn = append(withoutReturn, node)
+		}
+	}
+	sort.Strings(withoutReturn)
+	return withoutReturn
+}
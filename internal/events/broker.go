@@ -0,0 +1,122 @@
+package events
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// Tipos de evento que se publican hacia los clientes
+const (
+	ZoneUpdated       = "zone_updated"
+	ConnectionUpdated = "connection_updated"
+	TrafficChanged    = "traffic_changed"
+	ClosureStarted    = "closure_started"
+	ClosureEnded      = "closure_ended"
+	VehicleMoved      = "vehicle_moved"
+	VehicleUpdated    = "vehicle_updated"
+	NetworkRestored   = "network_restored"
+)
+
+type Event struct {
+	Type  string      `json:"type"`
+	Zones []string    `json:"zones"` // zonas afectadas, usadas para filtrar por cliente
+	Data  interface{} `json:"data"`
+	Time  time.Time   `json:"time"`
+}
+
+// Filtro de un suscriptor. Una lista vacía acepta cualquier valor
+type Filter struct {
+	Types []string
+	Zones []string
+}
+
+func (f Filter) matches(event Event) bool {
+	if len(f.Types) > 0 && !slices.Contains(f.Types, event.Type) {
+		return false
+	}
+	if len(f.Zones) == 0 {
+		return true
+	}
+	for _, zone := range event.Zones {
+		if slices.Contains(f.Zones, zone) {
+			return true
+		}
+	}
+	return false
+}
+
+type subscriber struct {
+	filter Filter
+	ch     chan Event
+	once   sync.Once
+}
+
+func (s *subscriber) close() {
+	s.once.Do(func() { close(s.ch) })
+}
+
+// Broker reparte los eventos publicados entre los suscriptores cuyo filtro los acepta.
+// Un suscriptor lento pierde eventos en lugar de bloquear a quien publica
+type Broker struct {
+	mu          sync.RWMutex
+	subscribers map[*subscriber]struct{}
+	closed      bool
+}
+
+func NewBroker() *Broker {
+	return &Broker{subscribers: make(map[*subscriber]struct{})}
+}
+
+// Subscribe retorna el canal de eventos y la función para cancelar la suscripción.
+// El canal se cierra al cancelar o al cerrar el broker; si ya estaba cerrado nace cerrado
+func (b *Broker) Subscribe(filter Filter) (<-chan Event, func()) {
+	sub := &subscriber{filter: filter, ch: make(chan Event, 32)}
+
+	b.mu.Lock()
+	if b.closed {
+		sub.close()
+	} else {
+		b.subscribers[sub] = struct{}{}
+	}
+	b.mu.Unlock()
+
+	return sub.ch, func() {
+		b.mu.Lock()
+		delete(b.subscribers, sub)
+		b.mu.Unlock()
+		sub.close()
+	}
+}
+
+// Close cierra los canales de todos los suscriptores para que los streams abiertos terminen,
+// por ejemplo al apagar el servidor
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for sub := range b.subscribers {
+		delete(b.subscribers, sub)
+		sub.close()
+	}
+}
+
+// Publish es seguro sobre un Broker nil, para que los servicios funcionen sin eventos
+func (b *Broker) Publish(eventType string, zones []string, data interface{}) {
+	if b == nil {
+		return
+	}
+	event := Event{Type: eventType, Zones: zones, Data: data, Time: time.Now()}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for sub := range b.subscribers {
+		if !sub.filter.matches(event) {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+		}
+	}
+}
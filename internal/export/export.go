@@ -0,0 +1,173 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"neo4j_delivery/internal/models"
+)
+
+const (
+	FormatDOT     = "dot"
+	FormatGraphML = "graphml"
+	FormatCSV     = "csv"
+
+	PartNodes = "nodes"
+	PartEdges = "edges"
+)
+
+// Tipo de contenido HTTP de cada formato
+var ContentTypes = map[string]string{
+	FormatDOT:     "text/vnd.graphviz",
+	FormatGraphML: "application/graphml+xml",
+	FormatCSV:     "text/csv",
+}
+
+// Colores de Graphviz según el nivel de tráfico del tramo
+var trafficColors = map[string]string{
+	"bajo":  "forestgreen",
+	"medio": "orange",
+	"alto":  "red",
+}
+
+// Write escribe el grafo en el formato pedido. part solo aplica a CSV (nodes o edges)
+func Write(w io.Writer, format, part string, data models.GraphData) error {
+	switch format {
+	case FormatDOT:
+		return WriteDOT(w, data)
+	case FormatGraphML:
+		return WriteGraphML(w, data)
+	case FormatCSV:
+		if part == PartEdges {
+			return WriteEdgesCSV(w, data)
+		}
+		return WriteNodesCSV(w, data)
+	}
+	return fmt.Errorf("unknown export format %q", format)
+}
+
+func dotQuote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
+// WriteDOT genera Graphviz coloreando los tramos por tráfico y con los cierres en línea discontinua
+func WriteDOT(w io.Writer, data models.GraphData) error {
+	var b strings.Builder
+	b.WriteString("digraph delivery {\n")
+	b.WriteString("  rankdir=LR;\n  node [shape=ellipse];\n")
+
+	for _, node := range data.Nodes {
+		shape := "ellipse"
+		if node.Label == "CentroDistribucion" {
+			shape = "box"
+		}
+		fmt.Fprintf(&b, "  %s [label=%s, shape=%s];\n", dotQuote(node.ID), dotQuote(node.Name), shape)
+	}
+	for _, link := range data.Links {
+		color, ok := trafficColors[link.Trafico_actual]
+		if !ok {
+			color = "gray"
+		}
+		style := "solid"
+		if !link.Accesible {
+			style = "dashed"
+		}
+		fmt.Fprintf(&b, "  %s -> %s [label=%s, color=%s, style=%s];\n",
+			dotQuote(link.Source), dotQuote(link.Target), dotQuote(strconv.FormatFloat(link.Tiempo_minutos, 'f', -1, 64)+" min"), color, style)
+	}
+
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func xmlEscape(value string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(value))
+	return b.String()
+}
+
+func optionalFloat(value *float64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*value, 'f', -1, 64)
+}
+
+// WriteGraphML genera GraphML legible por Gephi y yEd
+func WriteGraphML(w io.Writer, data models.GraphData) error {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<graphml xmlns="http://graphml.graphdrawing.org/xmlns">` + "\n")
+	b.WriteString(`  <key id="name" for="node" attr.name="name" attr.type="string"/>` + "\n")
+	b.WriteString(`  <key id="label" for="node" attr.name="label" attr.type="string"/>` + "\n")
+	b.WriteString(`  <key id="tipo" for="node" attr.name="tipo" attr.type="string"/>` + "\n")
+	b.WriteString(`  <key id="lat" for="node" attr.name="lat" attr.type="double"/>` + "\n")
+	b.WriteString(`  <key id="lng" for="node" attr.name="lng" attr.type="double"/>` + "\n")
+	b.WriteString(`  <key id="tiempo_minutos" for="edge" attr.name="tiempo_minutos" attr.type="double"/>` + "\n")
+	b.WriteString(`  <key id="trafico_actual" for="edge" attr.name="trafico_actual" attr.type="string"/>` + "\n")
+	b.WriteString(`  <key id="capacidad" for="edge" attr.name="capacidad" attr.type="int"/>` + "\n")
+	b.WriteString(`  <key id="accesible" for="edge" attr.name="accesible" attr.type="boolean"/>` + "\n")
+	b.WriteString(`  <graph id="delivery" edgedefault="directed">` + "\n")
+
+	for _, node := range data.Nodes {
+		fmt.Fprintf(&b, "    <node id=\"%s\">\n", xmlEscape(node.ID))
+		fmt.Fprintf(&b, "      <data key=\"name\">%s</data>\n", xmlEscape(node.Name))
+		fmt.Fprintf(&b, "      <data key=\"label\">%s</data>\n", xmlEscape(node.Label))
+		fmt.Fprintf(&b, "      <data key=\"tipo\">%s</data>\n", xmlEscape(node.Tipo))
+		if node.Lat != nil && node.Lng != nil {
+			fmt.Fprintf(&b, "      <data key=\"lat\">%s</data>\n", optionalFloat(node.Lat))
+			fmt.Fprintf(&b, "      <data key=\"lng\">%s</data>\n", optionalFloat(node.Lng))
+		}
+		b.WriteString("    </node>\n")
+	}
+	for i, link := range data.Links {
+		fmt.Fprintf(&b, "    <edge id=\"e%d\" source=\"%s\" target=\"%s\">\n", i, xmlEscape(link.Source), xmlEscape(link.Target))
+		fmt.Fprintf(&b, "      <data key=\"tiempo_minutos\">%s</data>\n", strconv.FormatFloat(link.Tiempo_minutos, 'f', -1, 64))
+		fmt.Fprintf(&b, "      <data key=\"trafico_actual\">%s</data>\n", xmlEscape(link.Trafico_actual))
+		fmt.Fprintf(&b, "      <data key=\"capacidad\">%d</data>\n", link.Capacidad)
+		fmt.Fprintf(&b, "      <data key=\"accesible\">%t</data>\n", link.Accesible)
+		b.WriteString("    </edge>\n")
+	}
+
+	b.WriteString("  </graph>\n</graphml>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func WriteNodesCSV(w io.Writer, data models.GraphData) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"id", "name", "label", "tipo", "lat", "lng"})
+	for _, node := range data.Nodes {
+		writer.Write([]string{node.ID, node.Name, node.Label, node.Tipo, optionalFloat(node.Lat), optionalFloat(node.Lng)})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// Los tramos se escriben con el nombre de sus zonas, no con el ID interno de Neo4j, que cambia entre
+// bases; así el archivo se puede volver a importar con las mismas columnas que lee el importador
+func WriteEdgesCSV(w io.Writer, data models.GraphData) error {
+	names := make(map[string]string, len(data.Nodes))
+	for _, node := range data.Nodes {
+		names[node.ID] = node.Name
+	}
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"source", "target", "tiempo_minutos", "trafico_actual", "capacidad", "accesible"})
+	for _, link := range data.Links {
+		writer.Write([]string{
+			names[link.Source],
+			names[link.Target],
+			strconv.FormatFloat(link.Tiempo_minutos, 'f', -1, 64),
+			link.Trafico_actual,
+			strconv.Itoa(link.Capacidad),
+			strconv.FormatBool(link.Accesible),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
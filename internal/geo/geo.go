@@ -0,0 +1,128 @@
+package geo
+
+import (
+	"math"
+	"sort"
+)
+
+const earthRadiusKm = 6371.0
+
+type Point struct {
+	Lat float64
+	Lng float64
+}
+
+// Distancia de círculo máximo (haversine) en kilómetros
+func DistanceKm(a, b Point) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := toRad(b.Lat - a.Lat)
+	dLng := toRad(b.Lng - a.Lng)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(a.Lat))*math.Cos(toRad(b.Lat))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// Zona con su centroide y, si lo tiene, su polígono como pares [lng, lat]
+type Region struct {
+	Name     string
+	Centroid *Point
+	Boundary [][2]float64
+}
+
+type boundingBox struct {
+	minLng, minLat, maxLng, maxLat float64
+}
+
+func (b boundingBox) contains(p Point) bool {
+	return p.Lng >= b.minLng && p.Lng <= b.maxLng && p.Lat >= b.minLat && p.Lat <= b.maxLat
+}
+
+type indexedRegion struct {
+	region Region
+	box    boundingBox
+}
+
+// Index ubica puntos dentro de las regiones. Las cajas envolventes se ordenan por longitud
+// mínima, así cada búsqueda solo prueba el polígono de las regiones cuya caja contiene el punto
+type Index struct {
+	polygons []indexedRegion
+	regions  []Region
+}
+
+func NewIndex(regions []Region) *Index {
+	index := &Index{regions: regions}
+	for _, region := range regions {
+		if len(region.Boundary) < 3 {
+			continue
+		}
+		box := boundingBox{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+		for _, vertex := range region.Boundary {
+			box.minLng = math.Min(box.minLng, vertex[0])
+			box.maxLng = math.Max(box.maxLng, vertex[0])
+			box.minLat = math.Min(box.minLat, vertex[1])
+			box.maxLat = math.Max(box.maxLat, vertex[1])
+		}
+		index.polygons = append(index.polygons, indexedRegion{region: region, box: box})
+	}
+	sort.Slice(index.polygons, func(i, j int) bool {
+		return index.polygons[i].box.minLng < index.polygons[j].box.minLng
+	})
+	return index
+}
+
+// Ray casting sobre el anillo del polígono
+func pointInPolygon(p Point, ring [][2]float64) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		xi, yi := ring[i][0], ring[i][1]
+		xj, yj := ring[j][0], ring[j][1]
+		if (yi > p.Lat) != (yj > p.Lat) && p.Lng < (xj-xi)*(p.Lat-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+const (
+	MethodPolygon  = "polygon"
+	MethodCentroid = "nearest_centroid"
+)
+
+// Locate retorna la región que contiene el punto, el método usado y la distancia a su centroide.
+// Si ningún polígono lo contiene se usa el centroide más cercano; ok es false si no hay candidatos
+func (idx *Index) Locate(p Point) (region Region, method string, distanceKm float64, ok bool) {
+	// Solo las cajas con minLng <= p.Lng pueden contener el punto
+	limit := sort.Search(len(idx.polygons), func(i int) bool {
+		return idx.polygons[i].box.minLng > p.Lng
+	})
+
+	best, bestDistance := -1, math.Inf(1)
+	for i := 0; i < limit; i++ {
+		candidate := idx.polygons[i]
+		if !candidate.box.contains(p) || !pointInPolygon(p, candidate.region.Boundary) {
+			continue
+		}
+		// Si los polígonos se solapan gana el de centroide más cercano
+		distance := 0.0
+		if candidate.region.Centroid != nil {
+			distance = DistanceKm(p, *candidate.region.Centroid)
+		}
+		if best < 0 || distance < bestDistance {
+			best, bestDistance = i, distance
+		}
+	}
+	if best >= 0 {
+		return idx.polygons[best].region, MethodPolygon, bestDistance, true
+	}
+
+	for _, candidate := range idx.regions {
+		if candidate.Centroid == nil {
+			continue
+		}
+		distance := DistanceKm(p, *candidate.Centroid)
+		if !ok || distance < distanceKm {
+			region, distanceKm, ok = candidate, distance, true
+		}
+	}
+	return region, MethodCentroid, distanceKm, ok
+}
@@ -0,0 +1,128 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"neo4j_delivery/internal/models"
+)
+
+// Tiempo máximo de cada comprobación; una dependencia colgada cuenta como caída
+var CheckTimeout = 3 * time.Second
+
+type check struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+// Checker reúne las comprobaciones de dependencias que deciden si el servidor está listo
+type Checker struct {
+	started time.Time
+
+	mu     sync.Mutex
+	checks []check
+}
+
+func NewChecker() *Checker {
+	return &Checker{started: time.Now()}
+}
+
+func (c *Checker) Add(name string, run func(ctx context.Context) error) {
+	c.mu.Lock()
+	c.checks = append(c.checks, check{name: name, run: run})
+	c.mu.Unlock()
+}
+
+// Ejecuta todas las comprobaciones en paralelo. El servidor está listo si todas pasan
+func (c *Checker) Check(ctx context.Context) models.HealthReport {
+	c.mu.Lock()
+	checks := append([]check{}, c.checks...)
+	c.mu.Unlock()
+
+	report := c.report(models.HealthUp)
+	report.Checks = make(map[string]models.HealthCheck, len(checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, chk := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := run(ctx, chk)
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[chk.name] = result
+			if result.Status != models.HealthUp {
+				report.Status = models.HealthDown
+			}
+		}()
+	}
+	wg.Wait()
+	return report
+}
+
+func run(ctx context.Context, chk check) models.HealthCheck {
+	ctx, cancel := context.WithTimeout(ctx, CheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("check panicked: %v", r)
+			}
+		}()
+		done <- chk.run(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("timed out after %s", CheckTimeout)
+	}
+	result := models.HealthCheck{
+		Status:     models.HealthUp,
+		DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status, result.Error = models.HealthDown, err.Error()
+	}
+	return result
+}
+
+func (c *Checker) report(status string) models.HealthReport {
+	return models.HealthReport{
+		Status:    status,
+		Uptime:    time.Since(c.started).Round(time.Second).String(),
+		CheckedAt: time.Now().UTC(),
+	}
+}
+
+// Liveness: responde mientras el proceso atienda peticiones, sin mirar dependencias,
+// para que el orquestador no reinicie el servidor por una caída de Neo4j
+func (c *Checker) LiveHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeReport(w, c.report(models.HealthUp))
+	}
+}
+
+// Readiness: 200 si todas las dependencias responden, 503 con el detalle si alguna falla
+func (c *Checker) ReadyHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeReport(w, c.Check(r.Context()))
+	}
+}
+
+func writeReport(w http.ResponseWriter, report models.HealthReport) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if report.Status != models.HealthUp {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(report)
+}
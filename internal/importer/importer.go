@@ -0,0 +1,254 @@
+package importer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"neo4j_delivery/internal/models"
+)
+
+var ErrInvalidFile = errors.New("invalid import file")
+
+// Niveles de tráfico aceptados en trafico_actual
+var TrafficLevels = []string{"bajo", "medio", "alto"}
+
+func ParseJSON(r io.Reader) (models.ImportData, error) {
+	var data models.ImportData
+	if err := json.NewDecoder(r).Decode(&data); err != nil {
+		return models.ImportData{}, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	return data, nil
+}
+
+// Lee un CSV con cabecera y retorna cada fila como mapa columna -> valor
+func readCSV(r io.Reader, required ...string) ([]map[string]string, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	if len(records) == 0 {
+		return []map[string]string{}, nil
+	}
+
+	header := records[0]
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(header[i]))
+	}
+	for _, column := range required {
+		found := false
+		for _, name := range header {
+			if name == column {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: missing column %q", ErrInvalidFile, column)
+		}
+	}
+
+	rows := make([]map[string]string, 0, len(records)-1)
+	for _, record := range records[1:] {
+		row := make(map[string]string, len(header))
+		for i, value := range record {
+			if i < len(header) {
+				row[header[i]] = strings.TrimSpace(value)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func parseOptionalInt(value string, line int, column string) (*int, error) {
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: line %d: %s must be an integer", ErrInvalidFile, line, column)
+	}
+	return &parsed, nil
+}
+
+func parseOptionalFloat(value string, line int, column string) (*float64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: line %d: %s must be a number", ErrInvalidFile, line, column)
+	}
+	return &parsed, nil
+}
+
+func parseOptionalBool(value string, line int, column string) (*bool, error) {
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: line %d: %s must be true or false", ErrInvalidFile, line, column)
+	}
+	return &parsed, nil
+}
+
+// Columnas: nombre, tipo_zona, centro_distribucion, capacidad_vehiculos, poblacion, latitud, longitud
+func ParseZonesCSV(r io.Reader) ([]models.ImportZone, error) {
+	rows, err := readCSV(r, "nombre", "tipo_zona")
+	if err != nil {
+		return nil, err
+	}
+
+	zones := make([]models.ImportZone, 0, len(rows))
+	for i, row := range rows {
+		line := i + 2
+		zone := models.ImportZone{Nombre: row["nombre"], TipoZona: row["tipo_zona"]}
+		centro, err := parseOptionalBool(row["centro_distribucion"], line, "centro_distribucion")
+		if err != nil {
+			return nil, err
+		}
+		zone.CentroDistribucion = centro != nil && *centro
+		capacidad, err := parseOptionalInt(row["capacidad_vehiculos"], line, "capacidad_vehiculos")
+		if err != nil {
+			return nil, err
+		}
+		if capacidad != nil {
+			zone.CapacidadVehiculos = *capacidad
+		}
+		if zone.Poblacion, err = parseOptionalInt(row["poblacion"], line, "poblacion"); err != nil {
+			return nil, err
+		}
+		if zone.Latitud, err = parseOptionalFloat(row["latitud"], line, "latitud"); err != nil {
+			return nil, err
+		}
+		if zone.Longitud, err = parseOptionalFloat(row["longitud"], line, "longitud"); err != nil {
+			return nil, err
+		}
+		zones = append(zones, zone)
+	}
+	return zones, nil
+}
+
+// Columnas: source, target, tiempo_minutos, trafico_actual, capacidad, accesible
+func ParseConnectionsCSV(r io.Reader) ([]models.ImportConnection, error) {
+	rows, err := readCSV(r, "source", "target", "tiempo_minutos")
+	if err != nil {
+		return nil, err
+	}
+
+	connections := make([]models.ImportConnection, 0, len(rows))
+	for i, row := range rows {
+		line := i + 2
+		connection := models.ImportConnection{Source: row["source"], Target: row["target"], Trafico: row["trafico_actual"]}
+		tiempo, err := parseOptionalInt(row["tiempo_minutos"], line, "tiempo_minutos")
+		if err != nil {
+			return nil, err
+		}
+		if tiempo != nil {
+			connection.TiempoMinutos = *tiempo
+		}
+		capacidad, err := parseOptionalInt(row["capacidad"], line, "capacidad")
+		if err != nil {
+			return nil, err
+		}
+		if capacidad != nil {
+			connection.Capacidad = *capacidad
+		}
+		if connection.Accesible, err = parseOptionalBool(row["accesible"], line, "accesible"); err != nil {
+			return nil, err
+		}
+		connections = append(connections, connection)
+	}
+	return connections, nil
+}
+
+// Validate revisa los datos a importar contra las zonas que ya existen en la base.
+// Detecta zonas sin nombre o repetidas, zonas nuevas sin tipo_zona, tramos con zonas desconocidas, tramos duplicados,
+// lazos, tiempos no positivos, capacidades negativas y niveles de tráfico desconocidos
+func Validate(data models.ImportData, existingZones map[string]bool) []models.ImportIssue {
+	issues := []models.ImportIssue{}
+	zoneIssue := func(row int, format string, args ...interface{}) {
+		issues = append(issues, models.ImportIssue{Kind: "zone", Row: row, Message: fmt.Sprintf(format, args...)})
+	}
+	connectionIssue := func(row int, format string, args ...interface{}) {
+		issues = append(issues, models.ImportIssue{Kind: "connection", Row: row, Message: fmt.Sprintf(format, args...)})
+	}
+
+	known := make(map[string]bool, len(existingZones)+len(data.Zones))
+	for name := range existingZones {
+		known[name] = true
+	}
+	seenZones := make(map[string]int)
+	for i, zone := range data.Zones {
+		row := i + 1
+		if zone.Nombre == "" {
+			zoneIssue(row, "nombre is required")
+			continue
+		}
+		if first, exists := seenZones[zone.Nombre]; exists {
+			zoneIssue(row, "zone %q is repeated (first at row %d)", zone.Nombre, first)
+		}
+		seenZones[zone.Nombre] = row
+		known[zone.Nombre] = true
+		// En una zona existente tipo_zona vacío conserva el guardado; una nueva no tendría ninguno
+		if zone.TipoZona == "" && !existingZones[zone.Nombre] {
+			zoneIssue(row, "tipo_zona is required for new zone %q", zone.Nombre)
+		}
+		if zone.CapacidadVehiculos < 0 {
+			zoneIssue(row, "capacidad_vehiculos cannot be negative")
+		}
+		if zone.Poblacion != nil && *zone.Poblacion < 0 {
+			zoneIssue(row, "poblacion cannot be negative")
+		}
+		if (zone.Latitud == nil) != (zone.Longitud == nil) {
+			zoneIssue(row, "latitud and longitud must be given together")
+		}
+	}
+
+	type segment struct{ source, target string }
+	seenConnections := make(map[segment]int)
+	for i, connection := range data.Connections {
+		row := i + 1
+		if !known[connection.Source] {
+			connectionIssue(row, "unknown source zone %q", connection.Source)
+		}
+		if !known[connection.Target] {
+			connectionIssue(row, "unknown target zone %q", connection.Target)
+		}
+		if connection.Source == connection.Target {
+			connectionIssue(row, "self-loop on %q", connection.Source)
+		}
+		key := segment{connection.Source, connection.Target}
+		if first, exists := seenConnections[key]; exists {
+			connectionIssue(row, "duplicate connection %s -> %s (first at row %d)", connection.Source, connection.Target, first)
+		}
+		seenConnections[key] = row
+		if connection.TiempoMinutos <= 0 {
+			connectionIssue(row, "tiempo_minutos must be positive, got %d", connection.TiempoMinutos)
+		}
+		if connection.Capacidad < 0 {
+			connectionIssue(row, "capacidad cannot be negative")
+		}
+		if connection.Trafico != "" && !isTrafficLevel(connection.Trafico) {
+			connectionIssue(row, "unknown trafico_actual %q", connection.Trafico)
+		}
+	}
+	return issues
+}
+
+func isTrafficLevel(value string) bool {
+	for _, level := range TrafficLevels {
+		if level == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,198 @@
+package importer
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"neo4j_delivery/internal/models"
+)
+
+func TestValidate(t *testing.T) {
+	existing := map[string]bool{"Centro Principal": true, "AltaVista": true}
+	zone := func(nombre, tipo string) models.ImportZone {
+		return models.ImportZone{Nombre: nombre, TipoZona: tipo}
+	}
+	connection := func(source, target string, minutes int) models.ImportConnection {
+		return models.ImportConnection{Source: source, Target: target, TiempoMinutos: minutes}
+	}
+	latitude := 8.3
+	negative := -1
+
+	cases := []struct {
+		name string
+		data models.ImportData
+		want []models.ImportIssue
+	}{
+		{
+			name: "valid data",
+			data: models.ImportData{
+				Zones:       []models.ImportZone{zone("Unare", "comercial")},
+				Connections: []models.ImportConnection{connection("AltaVista", "Unare", 10), connection("Unare", "Centro Principal", 12)},
+			},
+			want: []models.ImportIssue{},
+		},
+		{
+			name: "existing zone keeps its tipo_zona",
+			data: models.ImportData{Zones: []models.ImportZone{zone("AltaVista", "")}},
+			want: []models.ImportIssue{},
+		},
+		{
+			name: "new zone without tipo_zona",
+			data: models.ImportData{Zones: []models.ImportZone{zone("Unare", "")}},
+			want: []models.ImportIssue{{Kind: "zone", Row: 1, Message: `tipo_zona is required for new zone "Unare"`}},
+		},
+		{
+			name: "zone without nombre",
+			data: models.ImportData{Zones: []models.ImportZone{zone("", "comercial")}},
+			want: []models.ImportIssue{{Kind: "zone", Row: 1, Message: "nombre is required"}},
+		},
+		{
+			name: "repeated zone",
+			data: models.ImportData{Zones: []models.ImportZone{zone("Unare", "comercial"), zone("Cauca", "mixto"), zone("Unare", "comercial")}},
+			want: []models.ImportIssue{{Kind: "zone", Row: 3, Message: `zone "Unare" is repeated (first at row 1)`}},
+		},
+		{
+			name: "invalid zone values",
+			data: models.ImportData{Zones: []models.ImportZone{
+				{Nombre: "Unare", TipoZona: "comercial", CapacidadVehiculos: -2, Poblacion: &negative, Latitud: &latitude},
+			}},
+			want: []models.ImportIssue{
+				{Kind: "zone", Row: 1, Message: "capacidad_vehiculos cannot be negative"},
+				{Kind: "zone", Row: 1, Message: "poblacion cannot be negative"},
+				{Kind: "zone", Row: 1, Message: "latitud and longitud must be given together"},
+			},
+		},
+		{
+			name: "unknown zones",
+			data: models.ImportData{Connections: []models.ImportConnection{connection("Unare", "Cauca", 10)}},
+			want: []models.ImportIssue{
+				{Kind: "connection", Row: 1, Message: `unknown source zone "Unare"`},
+				{Kind: "connection", Row: 1, Message: `unknown target zone "Cauca"`},
+			},
+		},
+		{
+			name: "self-loop",
+			data: models.ImportData{Connections: []models.ImportConnection{connection("AltaVista", "AltaVista", 10)}},
+			want: []models.ImportIssue{{Kind: "connection", Row: 1, Message: `self-loop on "AltaVista"`}},
+		},
+		{
+			name: "duplicate connection",
+			data: models.ImportData{Connections: []models.ImportConnection{
+				connection("AltaVista", "Centro Principal", 10),
+				connection("Centro Principal", "AltaVista", 10),
+				connection("AltaVista", "Centro Principal", 15),
+			}},
+			want: []models.ImportIssue{{Kind: "connection", Row: 3, Message: "duplicate connection AltaVista -> Centro Principal (first at row 1)"}},
+		},
+		{
+			name: "non-positive times",
+			data: models.ImportData{Connections: []models.ImportConnection{
+				connection("AltaVista", "Centro Principal", 0),
+				connection("Centro Principal", "AltaVista", -5),
+			}},
+			want: []models.ImportIssue{
+				{Kind: "connection", Row: 1, Message: "tiempo_minutos must be positive, got 0"},
+				{Kind: "connection", Row: 2, Message: "tiempo_minutos must be positive, got -5"},
+			},
+		},
+		{
+			name: "invalid connection values",
+			data: models.ImportData{Connections: []models.ImportConnection{
+				{Source: "AltaVista", Target: "Centro Principal", TiempoMinutos: 10, Capacidad: -1, Trafico: "extremo"},
+			}},
+			want: []models.ImportIssue{
+				{Kind: "connection", Row: 1, Message: "capacidad cannot be negative"},
+				{Kind: "connection", Row: 1, Message: `unknown trafico_actual "extremo"`},
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Validate(tc.data, existing); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Validate() =\n%+v\nwant\n%+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseZonesCSV(t *testing.T) {
+	input := "Nombre, tipo_zona, centro_distribucion, capacidad_vehiculos, poblacion, latitud, longitud\n" +
+		"Unare, comercial, , , 1200, 8.278, -62.77\n" +
+		"Centro Norte, logistica, true, 20, , ,\n"
+	zones, err := ParseZonesCSV(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	poblacion, lat, lng := 1200, 8.278, -62.77
+	want := []models.ImportZone{
+		{Nombre: "Unare", TipoZona: "comercial", Poblacion: &poblacion, Latitud: &lat, Longitud: &lng},
+		{Nombre: "Centro Norte", TipoZona: "logistica", CentroDistribucion: true, CapacidadVehiculos: 20},
+	}
+	if !reflect.DeepEqual(zones, want) {
+		t.Errorf("ParseZonesCSV() = %+v, want %+v", zones, want)
+	}
+}
+
+func TestParseConnectionsCSV(t *testing.T) {
+	input := "source,target,tiempo_minutos,trafico_actual,capacidad,accesible\n" +
+		"Unare,AltaVista,12,medio,40,false\n" +
+		"AltaVista,Unare,12,,,\n"
+	connections, err := ParseConnectionsCSV(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed := false
+	want := []models.ImportConnection{
+		{Source: "Unare", Target: "AltaVista", TiempoMinutos: 12, Trafico: "medio", Capacidad: 40, Accesible: &closed},
+		{Source: "AltaVista", Target: "Unare", TiempoMinutos: 12},
+	}
+	if !reflect.DeepEqual(connections, want) {
+		t.Errorf("ParseConnectionsCSV() = %+v, want %+v", connections, want)
+	}
+}
+
+// Los errores de lectura indican la línea del archivo, contando la cabecera como línea 1
+func TestParseCSVErrors(t *testing.T) {
+	cases := []struct {
+		name  string
+		parse func(string) error
+		input string
+		want  string
+	}{
+		{"zones missing column", parseZones, "nombre\nUnare\n", `missing column "tipo_zona"`},
+		{"zones bad boolean", parseZones, "nombre,tipo_zona,centro_distribucion\nUnare,comercial,\nCauca,mixto,quizas\n",
+			"line 3: centro_distribucion must be true or false"},
+		{"zones bad integer", parseZones, "nombre,tipo_zona,poblacion\nUnare,comercial,mucha\n", "line 2: poblacion must be an integer"},
+		{"zones bad number", parseZones, "nombre,tipo_zona,latitud,longitud\nUnare,comercial,8.2,\nCauca,mixto,norte,1\n",
+			"line 3: latitud must be a number"},
+		{"connections missing column", parseConnections, "source,target\nA,B\n", `missing column "tiempo_minutos"`},
+		{"connections bad time", parseConnections, "source,target,tiempo_minutos\nA,B,10\nB,C,10\nC,A,diez\n",
+			"line 4: tiempo_minutos must be an integer"},
+		{"connections bad boolean", parseConnections, "source,target,tiempo_minutos,accesible\nA,B,10,si\n",
+			"line 2: accesible must be true or false"},
+		{"malformed csv", parseConnections, "source,target,tiempo_minutos\nA,\"B,10\n", "invalid import file"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.parse(tc.input)
+			if !errors.Is(err, ErrInvalidFile) {
+				t.Fatalf("error = %v, want ErrInvalidFile", err)
+			}
+			if !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("error = %q, want it to contain %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func parseZones(input string) error {
+	_, err := ParseZonesCSV(strings.NewReader(input))
+	return err
+}
+
+func parseConnections(input string) error {
+	_, err := ParseConnectionsCSV(strings.NewReader(input))
+	return err
+}
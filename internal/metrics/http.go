@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"neo4j_delivery/internal/logging"
+)
+
+var (
+	httpRequests = NewCounterVec("http_requests_total",
+		"Peticiones HTTP atendidas por ruta, método y código de estado.", "route", "method", "status")
+	httpDuration = NewHistogramVec("http_request_duration_seconds",
+		"Duración de las peticiones HTTP por ruta y método.", nil, "route", "method")
+)
+
+// Middleware cuenta y mide las peticiones. La ruta es el patrón que eligió el ServeMux,
+// no la URL, para no crear una serie por cada parámetro; debe ir por fuera del router y
+// pasarle la misma petición para poder leer r.Pattern al terminar
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := logging.NewStatusRecorder(w)
+		start := time.Now()
+		next.ServeHTTP(recorder, r)
+
+		route := r.Pattern
+		if route == "" {
+			route = "unmatched"
+		}
+		httpRequests.Inc(route, r.Method, strconv.Itoa(recorder.Status()))
+		httpDuration.Observe(time.Since(start).Seconds(), route, r.Method)
+	})
+}
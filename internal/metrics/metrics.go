@@ -0,0 +1,300 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Límites por defecto de los histogramas, en segundos
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Registry guarda las métricas y las escribe en el formato de texto de Prometheus (0.0.4).
+// No depende de un servidor Prometheus: WriteTo sirve también para revisarlas desde un test
+type Registry struct {
+	mu         sync.Mutex
+	families   []family
+	collectors []func()
+}
+
+type family interface {
+	write(w *bufio.Writer)
+}
+
+// Registro donde se crean todas las métricas de la aplicación y que expone /metrics
+var Default = &Registry{}
+
+func (r *Registry) register(f family) {
+	r.mu.Lock()
+	r.families = append(r.families, f)
+	r.mu.Unlock()
+}
+
+// OnCollect agrega una función que se ejecuta antes de cada lectura, para actualizar
+// los gauges que se calculan a demanda
+func (r *Registry) OnCollect(collect func()) {
+	r.mu.Lock()
+	r.collectors = append(r.collectors, collect)
+	r.mu.Unlock()
+}
+
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	collectors := append([]func(){}, r.collectors...)
+	families := append([]family{}, r.families...)
+	r.mu.Unlock()
+
+	for _, collect := range collectors {
+		collect()
+	}
+	counter := &countingWriter{w: w}
+	buffered := bufio.NewWriter(counter)
+	for _, f := range families {
+		f.write(buffered)
+	}
+	err := buffered.Flush()
+	return counter.n, err
+}
+
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.WriteTo(w)
+	})
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(b []byte) (int, error) {
+	n, err := c.w.Write(b)
+	c.n += int64(n)
+	return n, err
+}
+
+// Series de una métrica indexadas por los valores de sus etiquetas
+type vec[T any] struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+
+	mu     sync.Mutex
+	series map[string]*entry[T]
+	init   func() *T
+}
+
+type entry[T any] struct {
+	values []string
+	value  *T
+}
+
+func newVec[T any](name, help, kind string, labels []string, init func() *T) *vec[T] {
+	v := &vec[T]{name: name, help: help, kind: kind, labels: labels, series: make(map[string]*entry[T]), init: init}
+	if len(labels) == 0 {
+		// Sin etiquetas hay una sola serie y se expone desde el inicio
+		v.get(nil)
+	}
+	return v
+}
+
+// Retorna la serie de los valores indicados creándola si hace falta. Debe llamarse con mu tomado
+func (v *vec[T]) get(values []string) *T {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", v.name, len(v.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	e, exists := v.series[key]
+	if !exists {
+		e = &entry[T]{values: append([]string{}, values...), value: v.init()}
+		v.series[key] = e
+	}
+	return e.value
+}
+
+// Series ordenadas por sus etiquetas, para que la salida sea estable
+func (v *vec[T]) sorted() []*entry[T] {
+	keys := make([]string, 0, len(v.series))
+	for key := range v.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	entries := make([]*entry[T], 0, len(keys))
+	for _, key := range keys {
+		entries = append(entries, v.series[key])
+	}
+	return entries
+}
+
+func (v *vec[T]) header(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", v.name, escapeHelp(v.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", v.name, v.kind)
+}
+
+// CounterVec es un contador que solo crece, con una serie por combinación de etiquetas
+type CounterVec struct {
+	*vec[float64]
+}
+
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{newVec(name, help, "counter", labels, func() *float64 { return new(float64) })}
+	Default.register(c)
+	return c
+}
+
+func (c *CounterVec) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+func (c *CounterVec) Add(delta float64, values ...string) {
+	if delta < 0 {
+		panic(fmt.Sprintf("metrics: counter %s cannot decrease", c.name))
+	}
+	c.mu.Lock()
+	*c.get(values) += delta
+	c.mu.Unlock()
+}
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.header(w)
+	for _, e := range c.sorted() {
+		writeSample(w, c.name, c.labels, e.values, "", "", *e.value)
+	}
+}
+
+// GaugeVec es un valor que sube y baja, con una serie por combinación de etiquetas
+type GaugeVec struct {
+	*vec[float64]
+}
+
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{newVec(name, help, "gauge", labels, func() *float64 { return new(float64) })}
+	Default.register(g)
+	return g
+}
+
+func (g *GaugeVec) Set(value float64, values ...string) {
+	g.mu.Lock()
+	*g.get(values) = value
+	g.mu.Unlock()
+}
+
+func (g *GaugeVec) write(w *bufio.Writer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.header(w)
+	for _, e := range g.sorted() {
+		writeSample(w, g.name, g.labels, e.values, "", "", *e.value)
+	}
+}
+
+// HistogramVec cuenta observaciones en cubetas acumuladas, más su suma y su total
+type HistogramVec struct {
+	*vec[histogram]
+	buckets []float64
+}
+
+type histogram struct {
+	counts []uint64 // una por cubeta, sin acumular
+	sum    float64
+	count  uint64
+}
+
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64{}, buckets...)
+	sort.Float64s(buckets)
+	h := &HistogramVec{buckets: buckets}
+	h.vec = newVec(name, help, "histogram", labels, func() *histogram {
+		return &histogram{counts: make([]uint64, len(buckets))}
+	})
+	Default.register(h)
+	return h
+}
+
+func (h *HistogramVec) Observe(value float64, values ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	series := h.get(values)
+	for i, bound := range h.buckets {
+		if value <= bound {
+			series.counts[i]++
+			break
+		}
+	}
+	series.sum += value
+	series.count++
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.header(w)
+	for _, e := range h.sorted() {
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += e.value.counts[i]
+			writeSample(w, h.name+"_bucket", h.labels, e.values, "le", formatFloat(bound), float64(cumulative))
+		}
+		writeSample(w, h.name+"_bucket", h.labels, e.values, "le", "+Inf", float64(e.value.count))
+		writeSample(w, h.name+"_sum", h.labels, e.values, "", "", e.value.sum)
+		writeSample(w, h.name+"_count", h.labels, e.values, "", "", float64(e.value.count))
+	}
+}
+
+// Escribe una línea de muestra; extraName y extraValue agregan una etiqueta más, como le
+func writeSample(w *bufio.Writer, name string, labels, values []string, extraName, extraValue string, value float64) {
+	w.WriteString(name)
+	if len(labels) > 0 || extraName != "" {
+		w.WriteByte('{')
+		for i, label := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, "%s=\"%s\"", label, escapeLabel(values[i]))
+		}
+		if extraName != "" {
+			if len(labels) > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, "%s=\"%s\"", extraName, escapeLabel(extraValue))
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(value string) string { return labelEscaper.Replace(value) }
+
+func escapeHelp(value string) string { return helpEscaper.Replace(value) }
@@ -0,0 +1,117 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Lee lo que expone el handler de /metrics a través de un servidor real
+func scrape(t *testing.T, handler http.Handler) string {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	response, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	if got := response.Header.Get("Content-Type"); got != "text/plain; version=0.0.4; charset=utf-8" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func TestRegistryExposition(t *testing.T) {
+	// Las métricas también quedan en Default; se registran además en un registro propio para
+	// comparar la salida completa
+	registry := &Registry{}
+	requests := NewCounterVec("test_requests_total", "Peticiones\nde prueba.", "route", "status")
+	registry.register(requests)
+	duration := NewHistogramVec("test_duration_seconds", "Duración de prueba.", []float64{1, 0.1})
+	registry.register(duration)
+	queue := NewGaugeVec("test_queue_size", "Tamaño de la cola.", "queue")
+	registry.register(queue)
+	collected := 0
+	registry.OnCollect(func() {
+		collected++
+		queue.Set(float64(collected*3), "pending")
+	})
+
+	requests.Inc("/api/zones", "200")
+	requests.Add(2, "/api/zones", "200")
+	requests.Inc(`/api/"raw"`, "500")
+	duration.Observe(0.05)
+	duration.Observe(0.1)
+	duration.Observe(0.5)
+	duration.Observe(3)
+
+	want := strings.Join([]string{
+		`# HELP test_requests_total Peticiones\nde prueba.`,
+		`# TYPE test_requests_total counter`,
+		`test_requests_total{route="/api/\"raw\"",status="500"} 1`,
+		`test_requests_total{route="/api/zones",status="200"} 3`,
+		`# HELP test_duration_seconds Duración de prueba.`,
+		`# TYPE test_duration_seconds histogram`,
+		`test_duration_seconds_bucket{le="0.1"} 2`,
+		`test_duration_seconds_bucket{le="1"} 3`,
+		`test_duration_seconds_bucket{le="+Inf"} 4`,
+		`test_duration_seconds_sum 3.65`,
+		`test_duration_seconds_count 4`,
+		`# HELP test_queue_size Tamaño de la cola.`,
+		`# TYPE test_queue_size gauge`,
+		`test_queue_size{queue="pending"} 3`,
+	}, "\n") + "\n"
+	if got := scrape(t, registry.Handler()); got != want {
+		t.Errorf("exposition:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Cada lectura vuelve a ejecutar los collectors
+	if got := scrape(t, registry.Handler()); !strings.Contains(got, "\ntest_queue_size{queue=\"pending\"} 6\n") {
+		t.Errorf("gauge was not refreshed on the second scrape:\n%s", got)
+	}
+}
+
+func TestCounterCannotDecrease(t *testing.T) {
+	counter := NewCounterVec("test_decrease_total", "Contador de prueba.")
+	defer func() {
+		if recover() == nil {
+			t.Error("Add with a negative delta did not panic")
+		}
+	}()
+	counter.Add(-1)
+}
+
+func TestMiddlewareRecordsRoutePattern(t *testing.T) {
+	router := http.NewServeMux()
+	router.HandleFunc("GET /test/zones/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	server := httptest.NewServer(Middleware(router))
+	defer server.Close()
+	for _, path := range []string{"/test/zones/a", "/test/zones/b", "/test/missing"} {
+		response, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+	}
+
+	exposition := scrape(t, Default.Handler())
+	for _, line := range []string{
+		`http_requests_total{route="GET /test/zones/{id}",method="GET",status="418"} 2`,
+		`http_requests_total{route="unmatched",method="GET",status="404"} 1`,
+		`http_request_duration_seconds_bucket{route="GET /test/zones/{id}",method="GET",le="+Inf"} 2`,
+		`http_request_duration_seconds_count{route="GET /test/zones/{id}",method="GET"} 2`,
+	} {
+		if !strings.Contains(exposition, "\n"+line+"\n") {
+			t.Errorf("missing line %s in:\n%s", line, exposition)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	AuditEntityZone    = "zone"
+	AuditEntitySegment = "segment"
+	AuditEntityNetwork = "network" // afecta a toda la red, aparece en el historial de cualquier zona o tramo
+
+	AuditActionUpdateSegment    = "update_segment"
+	AuditActionImportZone       = "import_zone"
+	AuditActionImportConnection = "import_connection"
+	AuditActionRestoreSnapshot  = "restore_snapshot"
+)
+
+// Registro de una modificación de la red. Before es null cuando el elemento se creó
+type AuditEntry struct {
+	ID        string          `json:"id"`
+	Actor     string          `json:"actor"`
+	Role      string          `json:"role,omitempty"`
+	Action    string          `json:"action"`
+	Entity    string          `json:"entity"` // 'zone', 'segment' o 'network'
+	Zone      string          `json:"zone,omitempty"`
+	Source    string          `json:"source,omitempty"`
+	Target    string          `json:"target,omitempty"`
+	Timestamp time.Time       `json:"timestamp"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+}
+
+// Historial de una zona (incluye los tramos que salen o llegan a ella) o de un tramo source -> target
+type AuditQuery struct {
+	Zone   string
+	Source string
+	Target string
+	Limit  int // 0 usa el límite por defecto
+}
+
+// Propiedades de una zona o tramo antes y después de una escritura. Before es nil si se creó
+type PropertyChange struct {
+	Zone   string
+	Source string
+	Target string
+	Before interface{}
+	After  interface{}
+}
@@ -0,0 +1,29 @@
+package models
+
+// Estados posibles de un vehículo. Todos excepto 'inactivo' ocupan cupo en su centro
+const (
+	VehiculoDisponible    = "disponible"
+	VehiculoEnRuta        = "en_ruta"
+	VehiculoMantenimiento = "mantenimiento"
+	VehiculoInactivo      = "inactivo"
+)
+
+type Vehiculo struct {
+	ID             string  `json:"id"`
+	Placa          string  `json:"placa"`
+	Tipo           string  `json:"tipo"`            // 'moto', 'furgoneta', 'camion', ...
+	CapacidadCarga float64 `json:"capacidad_carga"` // kg
+	Estado         string  `json:"estado"`
+	Centro         string  `json:"centro"`
+}
+
+type Conductor struct {
+	ID          string `json:"id"`
+	Nombre      string `json:"nombre"`
+	Licencia    string `json:"licencia"`
+	Disponible  bool   `json:"disponible"`
+	TurnoInicio string `json:"turno_inicio"` // "HH:MM"
+	TurnoFin    string `json:"turno_fin"`    // "HH:MM"
+	Centro      string `json:"centro"`
+	Vehiculo    string `json:"vehiculo,omitempty"` // id del vehículo asignado
+}
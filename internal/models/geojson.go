@@ -0,0 +1,39 @@
+package models
+
+// Tipos mínimos de GeoJSON (RFC 7946). Las coordenadas siempre van en orden [lng, lat]
+
+type FeatureCollection struct {
+	Type     string    `json:"type"`
+	Features []Feature `json:"features"`
+}
+
+type Feature struct {
+	Type       string                 `json:"type"`
+	Geometry   Geometry               `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type Geometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+func NewFeatureCollection() FeatureCollection {
+	return FeatureCollection{Type: "FeatureCollection", Features: []Feature{}}
+}
+
+func PointFeature(lng, lat float64, properties map[string]interface{}) Feature {
+	return Feature{Type: "Feature", Geometry: Geometry{Type: "Point", Coordinates: [2]float64{lng, lat}}, Properties: properties}
+}
+
+func LineFeature(coordinates [][2]float64, properties map[string]interface{}) Feature {
+	return Feature{Type: "Feature", Geometry: Geometry{Type: "LineString", Coordinates: coordinates}, Properties: properties}
+}
+
+// El anillo del polígono se cierra repitiendo el primer punto si hace falta
+func PolygonFeature(ring [][2]float64, properties map[string]interface{}) Feature {
+	if len(ring) > 0 && ring[0] != ring[len(ring)-1] {
+		ring = append(append([][2]float64{}, ring...), ring[0])
+	}
+	return Feature{Type: "Feature", Geometry: Geometry{Type: "Polygon", Coordinates: [][][2]float64{ring}}, Properties: properties}
+}
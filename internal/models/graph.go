@@ -2,15 +2,42 @@ package models
 
 
 type GraphData struct {
-    Nodes []Node `json:"nodes"`
-    Links []Link `json:"links"`
+    Nodes []Node     `json:"nodes"`
+    Links []Link     `json:"links"`
+    Page  *GraphPage `json:"page,omitempty"`
+}
+
+// Paginación de /api/graph. Links solo trae los tramos entre los nodos de la página
+type GraphPage struct {
+    Offset     int  `json:"offset"`
+    Limit      int  `json:"limit"`
+    TotalNodes int  `json:"total_nodes"`
+    HasMore    bool `json:"has_more"`
+}
+
+// Filtros de /api/graph. Center con Hops o Minutes limita a la vecindad de una zona;
+// los filtros vacíos no se aplican
+type GraphQuery struct {
+    Center    string
+    Hops      int
+    Minutes   float64
+    Names     []string // zonas permitidas, la calcula el servicio a partir de Center
+    TipoZona  []string
+    Label     string
+    Trafico   []string
+    Accesible *bool
+    Offset    int
+    Limit     int // 0 retorna todos los nodos
 }
 
 type Node struct {
-    ID    string `json:"id"`
-    Name  string `json:"name"`
-    Label string `json:"label"`
-    Tipo  string `json:"tipo,omitempty"`
+    ID       string       `json:"id"`
+    Name     string       `json:"name"`
+    Label    string       `json:"label"`
+    Tipo     string       `json:"tipo,omitempty"`
+    Lat      *float64     `json:"lat,omitempty"`
+    Lng      *float64     `json:"lng,omitempty"`
+    Boundary [][2]float64 `json:"boundary,omitempty"` // pares [lng, lat] del polígono de la zona
 }
 
 type Link struct {
@@ -29,3 +56,51 @@ type Edge struct {
 }
 
 type Graph map[string][]Edge
+
+type ConnectivityReport struct {
+	Components       [][]string `json:"components"`
+	OneWayTraps      [][]string `json:"one_way_traps"`
+	NoReturnToCenter []string   `json:"no_return_to_center"`
+}
+
+type CriticalSegment struct {
+	Source           string   `json:"source"`
+	Target           string   `json:"target"`
+	CutOffZones      []string `json:"cut_off_zones"`
+	PopulationCutOff int      `json:"population_cut_off"`
+}
+
+type CriticalZone struct {
+	Zone             string   `json:"zone"`
+	CutOffZones      []string `json:"cut_off_zones"`
+	PopulationCutOff int      `json:"population_cut_off"`
+}
+
+type CriticalityReport struct {
+	Segments []CriticalSegment `json:"segments"`
+	Zones    []CriticalZone    `json:"zones"`
+}
+
+type ZoneCentrality struct {
+	Zone        string  `json:"zone"`
+	Betweenness float64 `json:"betweenness"`
+	Closeness   float64 `json:"closeness"`
+}
+
+type SegmentCentrality struct {
+	Source      string  `json:"source"`
+	Target      string  `json:"target"`
+	Betweenness float64 `json:"betweenness"`
+}
+
+type CentralityReport struct {
+	Zones    []ZoneCentrality    `json:"zones"`
+	Segments []SegmentCentrality `json:"segments"`
+}
+
+// Tamaño de la red para las métricas
+type NetworkSize struct {
+	Zones          int `json:"zones"`
+	Segments       int `json:"segments"`
+	ClosedSegments int `json:"closed_segments"`
+}
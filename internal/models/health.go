@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Estados de /healthz y /readyz
+const (
+	HealthUp   = "up"
+	HealthDown = "down"
+)
+
+type HealthCheck struct {
+	Status     string  `json:"status"`
+	Error      string  `json:"error,omitempty"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+type HealthReport struct {
+	Status    string                 `json:"status"`
+	Uptime    string                 `json:"uptime"`
+	CheckedAt time.Time              `json:"checked_at"`
+	Checks    map[string]HealthCheck `json:"checks,omitempty"`
+}
@@ -0,0 +1,52 @@
+package models
+
+type ImportZone struct {
+	Nombre             string   `json:"nombre"`
+	TipoZona           string   `json:"tipo_zona"`
+	CentroDistribucion bool     `json:"centro_distribucion,omitempty"`
+	CapacidadVehiculos int      `json:"capacidad_vehiculos,omitempty"`
+	Poblacion          *int     `json:"poblacion,omitempty"`
+	Latitud            *float64 `json:"latitud,omitempty"`
+	Longitud           *float64 `json:"longitud,omitempty"`
+}
+
+type ImportConnection struct {
+	Source        string `json:"source"`
+	Target        string `json:"target"`
+	TiempoMinutos int    `json:"tiempo_minutos"`
+	Trafico       string `json:"trafico_actual"`
+	Capacidad     int    `json:"capacidad"`
+	Accesible     *bool  `json:"accesible,omitempty"` // por defecto true
+}
+
+type ImportData struct {
+	Zones       []ImportZone       `json:"zones"`
+	Connections []ImportConnection `json:"connections"`
+}
+
+type ImportIssue struct {
+	Kind    string `json:"kind"`          // 'zone', 'connection' o 'network'
+	Row     int    `json:"row,omitempty"` // posición dentro de su lista, empezando en 1
+	Message string `json:"message"`
+}
+
+type ImportReport struct {
+	DryRun      bool          `json:"dry_run"`
+	Applied     bool          `json:"applied"`
+	Zones       int           `json:"zones"`
+	Connections int           `json:"connections"`
+	NewZones    []string      `json:"new_zones"`
+	Errors      []ImportIssue `json:"errors"`
+
+	// Incidencias de integridad que la importación introduciría en la red
+	Validation []ValidationIssue `json:"validation"`
+
+	// Snapshot tomado antes de aplicar la importación
+	BackupVersion int `json:"backup_version,omitempty"`
+}
+
+type ImportOptions struct {
+	DryRun    bool
+	Strict    bool // rechaza también si aparecen advertencias nuevas
+	BatchSize int
+}
@@ -0,0 +1,54 @@
+package models
+
+// Pedido a entregar en una zona. Si no trae ventana ni tiempo de servicio se usan los de la zona.
+// Si no trae zona pero sí coordenadas, la zona se ubica automáticamente
+type Order struct {
+	ID             string   `json:"id"`
+	Zone           string   `json:"zone,omitempty"`
+	Lat            *float64 `json:"lat,omitempty"`
+	Lng            *float64 `json:"lng,omitempty"`
+	WindowStart    string   `json:"window_start,omitempty"` // "HH:MM"
+	WindowEnd      string   `json:"window_end,omitempty"`   // "HH:MM"
+	ServiceMinutes float64  `json:"service_minutes,omitempty"`
+}
+
+type PlanRequest struct {
+	Center    string  `json:"center"`
+	StartTime string  `json:"start_time"` // "HH:MM", por defecto 08:00
+	Vehicles  int     `json:"vehicles"`   // por defecto 1
+	Orders    []Order `json:"orders"`
+}
+
+type PlannedStop struct {
+	OrderID      string  `json:"order_id"`
+	Zone         string  `json:"zone"`
+	Arrival      string  `json:"arrival"`
+	ServiceStart string  `json:"service_start"`
+	Departure    string  `json:"departure"`
+	WaitMinutes  float64 `json:"wait_minutes"`
+	WindowStart  string  `json:"window_start,omitempty"`
+	WindowEnd    string  `json:"window_end,omitempty"`
+}
+
+// Las horas van como "HH:MM"; si caen después de la medianoche llevan "+Nd" con los días
+// transcurridos. ReturnTime queda vacío cuando no hay camino desde la última parada al centro
+type VehicleRoute struct {
+	Vehicle           int           `json:"vehicle"`
+	Stops             []PlannedStop `json:"stops"`
+	ReturnTime        string        `json:"return_time,omitempty"`
+	ReturnUnreachable bool          `json:"return_unreachable,omitempty"`
+	TravelMinutes     float64       `json:"travel_minutes"`
+	WaitMinutes       float64       `json:"wait_minutes"`
+}
+
+type UnservedOrder struct {
+	OrderID string `json:"order_id"`
+	Zone    string `json:"zone"`
+	Reason  string `json:"reason"` // 'unreachable', 'window_missed' o 'no_vehicle'
+}
+
+type RoutePlan struct {
+	Center   string          `json:"center"`
+	Routes   []VehicleRoute  `json:"routes"`
+	Unserved []UnservedOrder `json:"unserved"`
+}
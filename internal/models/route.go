@@ -2,6 +2,13 @@ package models
 
 type Route struct {
 	Path   []string `json:"path"`
-	Time   float64  `json:"time`
-	Target string   `json:target`
+	Time   float64  `json:"time"`
+	Target string   `json:"target"`
+}
+
+type FlowResult struct {
+	From       []string     `json:"from"`
+	To         string       `json:"to"`
+	MaxFlow    int          `json:"max_flow"`
+	Bottleneck []Connection `json:"bottleneck"`
 }
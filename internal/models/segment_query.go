@@ -0,0 +1,34 @@
+package models
+
+// Filtros de /api/route/segments; los campos vacíos o nil no se aplican
+type SegmentQuery struct {
+	Trafico      []string
+	Accesible    *bool
+	MinCapacidad *int
+	MaxCapacidad *int
+	MinTiempo    *float64
+	MaxTiempo    *float64
+	Source       string
+	Target       string
+	SortBy       string // 'source', 'target', 'tiempo', 'capacidad' o 'trafico'
+	Desc         bool
+	Offset       int
+	Limit        int    // 0 retorna todos
+	GroupBy      string // 'source' (tramos salientes) o 'target' (entrantes) para agregar por zona
+}
+
+// Resumen de los tramos filtrados que salen de (o llegan a) una zona
+type ZoneSegmentStats struct {
+	Zone           string  `json:"zone"`
+	Segments       int     `json:"segments"`
+	Congested      int     `json:"congested"` // tráfico alto
+	Closed         int     `json:"closed"`
+	AvgTiempo      float64 `json:"avg_tiempo_minutos"`
+	TotalCapacidad int     `json:"total_capacidad"`
+}
+
+type SegmentQueryResult struct {
+	Items []Connection       `json:"items"`
+	Total int                `json:"total"`
+	Zones []ZoneSegmentStats `json:"zones,omitempty"`
+}
@@ -0,0 +1,32 @@
+package models
+
+type SegmentRef struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+type TrafficChange struct {
+	Source        string   `json:"source"`
+	Target        string   `json:"target"`
+	Trafico       string   `json:"trafico_actual"`
+	TiempoMinutos *float64 `json:"tiempo_minutos,omitempty"` // Si se indica, reemplaza el tiempo calculado por tráfico
+}
+
+type SimulationRequest struct {
+	Closures []SegmentRef    `json:"closures"`
+	Traffic  []TrafficChange `json:"traffic"`
+}
+
+type ZoneDelta struct {
+	Zone          string  `json:"zone"`
+	BeforeMinutes float64 `json:"before_minutes"`
+	AfterMinutes  float64 `json:"after_minutes"`
+	DeltaMinutes  float64 `json:"delta_minutes"`
+	Unreachable   bool    `json:"unreachable"`
+}
+
+type SimulationResult struct {
+	Deltas           []ZoneDelta `json:"deltas"`
+	NewlyUnreachable []string    `json:"newly_unreachable"`
+	MinutesLost      float64     `json:"minutes_lost"` // Suma de los deltas positivos; las zonas que mejoran no restan
+}
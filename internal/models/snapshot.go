@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Zona guardada en un snapshot con todas sus propiedades
+type SnapshotZone struct {
+	Nombre string                 `json:"nombre"`
+	Centro bool                   `json:"centro_distribucion"`
+	Props  map[string]interface{} `json:"props"`
+}
+
+// Tramo CONECTA guardado en un snapshot con todas sus propiedades
+type SnapshotSegment struct {
+	Source string                 `json:"source"`
+	Target string                 `json:"target"`
+	Props  map[string]interface{} `json:"props"`
+}
+
+type SnapshotInfo struct {
+	Version      int       `json:"version"`
+	CreatedAt    time.Time `json:"created_at"`
+	Actor        string    `json:"actor"`
+	Description  string    `json:"description,omitempty"`
+	ZoneCount    int       `json:"zone_count"`
+	SegmentCount int       `json:"segment_count"`
+}
+
+type Snapshot struct {
+	SnapshotInfo
+	Zones    []SnapshotZone    `json:"zones"`
+	Segments []SnapshotSegment `json:"segments"`
+}
+
+type SnapshotRequest struct {
+	Description string `json:"description"`
+}
+
+// Resultado de restaurar una versión. Backup es el snapshot tomado justo antes de restaurar
+type RestoreResult struct {
+	Restored SnapshotInfo `json:"restored"`
+	Backup   SnapshotInfo `json:"backup"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+type DispatchRequest struct {
+	VehiculoID string `json:"vehiculo_id"`
+	Destino    string `json:"destino"`
+}
+
+// Posición reportada por un vehículo: o está en una zona, o recorre un tramo con cierto progreso
+type PositionReport struct {
+	VehiculoID string  `json:"vehiculo_id"`
+	Zona       string  `json:"zona,omitempty"`
+	Source     string  `json:"source,omitempty"`
+	Target     string  `json:"target,omitempty"`
+	Progreso   float64 `json:"progreso,omitempty"` // 0 a 1 sobre el tramo source -> target
+}
+
+type VehicleTracking struct {
+	VehiculoID      string    `json:"vehiculo_id"`
+	Placa           string    `json:"placa"`
+	Destino         string    `json:"destino"`
+	RutaPlanificada []string  `json:"ruta_planificada"`
+	RutaRestante    []string  `json:"ruta_restante"`
+	Zona            string    `json:"zona,omitempty"`
+	Source          string    `json:"source,omitempty"`
+	Target          string    `json:"target,omitempty"`
+	Progreso        float64   `json:"progreso"`
+	Salida          time.Time `json:"salida"`
+	EtaPlanificada  time.Time `json:"eta_planificada"`
+	EtaActual       time.Time `json:"eta_actual"`
+	RetrasoMinutos  float64   `json:"retraso_minutos"`
+	Desviado        bool      `json:"desviado"` // la ruta planificada ya no es transitable y se recalculó
+	ReportadoEn     time.Time `json:"reportado_en"`
+}
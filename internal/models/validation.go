@@ -0,0 +1,34 @@
+package models
+
+// Zona tal como está guardada. Missing lista las propiedades ausentes o de tipo inesperado
+type ZoneRecord struct {
+	ID      int64    `json:"id"`
+	Nombre  string   `json:"nombre"`
+	Centro  bool     `json:"centro_distribucion"`
+	Missing []string `json:"missing,omitempty"`
+}
+
+// Tramo CONECTA tal como está guardado. Missing lista las propiedades ausentes o de tipo inesperado
+type SegmentRecord struct {
+	ID            int64    `json:"id"`
+	Source        string   `json:"source"`
+	Target        string   `json:"target"`
+	TiempoMinutos float64  `json:"tiempo_minutos"`
+	Accesible     bool     `json:"accesible"`
+	Missing       []string `json:"missing,omitempty"`
+}
+
+type ValidationIssue struct {
+	Category string   `json:"category"`
+	Severity string   `json:"severity"` // 'error' o 'warning'
+	Zones    []string `json:"zones,omitempty"`
+	Message  string   `json:"message"`
+}
+
+type ValidationReport struct {
+	Valid      bool              `json:"valid"` // sin errores, las advertencias no cuentan
+	Errors     int               `json:"errors"`
+	Warnings   int               `json:"warnings"`
+	Categories map[string]int    `json:"categories"`
+	Issues     []ValidationIssue `json:"issues"`
+}
@@ -1,10 +1,16 @@
 package models
 
 type Zone struct {
-	ID        string `json:"id"`
-	Nombre    string `json:"nombre"`
-	TipoZona  string `json:"tipo_zona"`
-	Poblacion *int   `json:"poblacion,omitempty"`
+	ID             string       `json:"id"`
+	Nombre         string       `json:"nombre"`
+	TipoZona       string       `json:"tipo_zona"`
+	Poblacion      *int         `json:"poblacion,omitempty"`
+	VentanaInicio  string       `json:"ventana_inicio,omitempty"` // "HH:MM"
+	VentanaFin     string       `json:"ventana_fin,omitempty"`    // "HH:MM"
+	TiempoServicio float64      `json:"tiempo_servicio,omitempty"`
+	Latitud        *float64     `json:"latitud,omitempty"`
+	Longitud       *float64     `json:"longitud,omitempty"`
+	Limite         [][2]float64 `json:"limite,omitempty"` // pares [lng, lat] del polígono de la zona
 }
 
 type DistributionCenter struct {
@@ -19,4 +25,19 @@ type Connection struct {
 	Trafico   string `json:"trafico_actual"`
 	Capacidad int    `json:"capacidad"`
 	Direccion string `json:"direccion"` // 'uni' o 'bi'
+	Accesible bool   `json:"accesible"`
+}
+
+// Cambios parciales sobre un tramo CONECTA; los campos nil no se modifican
+type SegmentUpdate struct {
+	Trafico       *string `json:"trafico_actual,omitempty"`
+	Accesible     *bool   `json:"accesible,omitempty"`
+	TiempoMinutos *int    `json:"tiempo_minutos,omitempty"`
+	Capacidad     *int    `json:"capacidad,omitempty"`
+}
+
+type ZoneLocation struct {
+	Zone       string  `json:"zone"`
+	Method     string  `json:"method"` // 'polygon' o 'nearest_centroid'
+	DistanceKm float64 `json:"distance_km"`
 }
@@ -0,0 +1,113 @@
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"sync"
+)
+
+// Documento OpenAPI 3.0. Solo se modelan las partes que usa la especificación de la API
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Tags       []Tag               `json:"tags,omitempty"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// Operaciones de una ruta indexadas por el método en minúsculas (get, post, ...)
+type PathItem map[string]*Operation
+
+type Operation struct {
+	Tags         []string              `json:"tags,omitempty"`
+	Summary      string                `json:"summary"`
+	Description  string                `json:"description,omitempty"`
+	OperationID  string                `json:"operationId"`
+	Parameters   []Parameter           `json:"parameters,omitempty"`
+	RequestBody  *RequestBody          `json:"requestBody,omitempty"`
+	Responses    map[string]Response   `json:"responses"`
+	Security     []map[string][]string `json:"security,omitempty"`
+	RequiredRole string                `json:"x-required-role,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Description string               `json:"description,omitempty"`
+	Required    bool                 `json:"required,omitempty"`
+	Content     map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Description  string `json:"description,omitempty"`
+	Name         string `json:"name,omitempty"`
+	In           string `json:"in,omitempty"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+//go:embed docs.html
+var docsPage []byte
+
+var (
+	specOnce sync.Once
+	specJSON []byte
+	specErr  error
+)
+
+// Handler sirve la especificación en JSON. Se genera una sola vez, la primera vez que se pide
+func Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		specOnce.Do(func() {
+			specJSON, specErr = json.MarshalIndent(Spec(), "", "  ")
+		})
+		if specErr != nil {
+			http.Error(w, specErr.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(specJSON)
+	}
+}
+
+// DocsHandler sirve la página de documentación, que lee la especificación desde /api/openapi.json.
+// No carga nada de internet para que funcione también sin conexión
+func DocsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(docsPage)
+	}
+}
@@ -0,0 +1,156 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Schema es el subconjunto de Schema Object de OpenAPI 3.0 que usan los modelos de la API
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+}
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	rawType  = reflect.TypeOf(json.RawMessage{})
+)
+
+// Registry genera los esquemas de los modelos por reflexión, siguiendo las mismas reglas que
+// encoding/json: el nombre sale de la etiqueta json, "-" se omite y omitempty deja el campo opcional.
+// Cada struct con nombre se registra una sola vez en components/schemas y se referencia con $ref
+type Registry struct {
+	schemas map[string]*Schema
+}
+
+func NewRegistry() *Registry {
+	return &Registry{schemas: make(map[string]*Schema)}
+}
+
+// Ref registra el tipo de value y retorna el esquema con que se referencia
+func (r *Registry) Ref(value interface{}) *Schema {
+	return r.schema(reflect.TypeOf(value))
+}
+
+// Schemas retorna los esquemas registrados, indexados por el nombre del tipo
+func (r *Registry) Schemas() map[string]*Schema {
+	return r.schemas
+}
+
+func (r *Registry) schema(t reflect.Type) *Schema {
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawType:
+		// JSON arbitrario, igual que interface{}
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return r.schema(t.Elem())
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Uint, reflect.Uint8, reflect.Uint16:
+		return &Schema{Type: "integer"}
+	case reflect.Int32, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice:
+		return &Schema{Type: "array", Items: r.schema(t.Elem())}
+	case reflect.Array:
+		length := t.Len()
+		return &Schema{Type: "array", Items: r.schema(t.Elem()), MinItems: &length, MaxItems: &length}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: r.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return r.object(t)
+		}
+		if _, exists := r.schemas[t.Name()]; !exists {
+			// Se reserva el nombre antes de recorrer los campos por si el tipo es recursivo
+			r.schemas[t.Name()] = &Schema{}
+			*r.schemas[t.Name()] = *r.object(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + t.Name()}
+	}
+	// interface{} y cualquier otro tipo aceptan cualquier valor
+	return &Schema{}
+}
+
+func (r *Registry) object(t reflect.Type) *Schema {
+	object := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	r.addFields(object, t)
+	return object
+}
+
+// Agrega los campos exportados de t; los structs embebidos sin etiqueta aportan sus campos, como en encoding/json
+func (r *Registry) addFields(object *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				r.addFields(object, embedded)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := r.schema(field.Type)
+		omitempty := strings.Contains(","+options+",", ",omitempty,")
+		if nilable(field.Type) && !omitempty {
+			// Un puntero, slice o map nil sin omitempty se escribe como null
+			if property.Ref != "" {
+				property = &Schema{AllOf: []*Schema{property}}
+			}
+			property.Nullable = true
+		}
+		object.Properties[name] = property
+		if !omitempty {
+			object.Required = append(object.Required, name)
+		}
+	}
+}
+
+func nilable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Map:
+		return true
+	}
+	return false
+}
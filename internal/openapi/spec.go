@@ -0,0 +1,737 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"neo4j_delivery/internal/auth"
+	"neo4j_delivery/internal/events"
+	"neo4j_delivery/internal/export"
+	"neo4j_delivery/internal/models"
+)
+
+// Versión de la API que se publica en info.version
+const APIVersion = "1.0.0"
+
+// Modelos de internal/models que viajan como JSON. Al agregar un modelo nuevo hay que sumarlo aquí
+// aunque ningún endpoint lo use todavía. Quedan fuera GraphQuery, SegmentQuery, AuditQuery e
+// ImportOptions, que se arman desde los parámetros de la URL, y Graph, Edge y PropertyChange, que
+// son internos de los servicios
+var modelTypes = []interface{}{
+	models.AuditEntry{},
+	models.Vehiculo{},
+	models.Conductor{},
+	models.FeatureCollection{},
+	models.Feature{},
+	models.Geometry{},
+	models.GraphData{},
+	models.GraphPage{},
+	models.Node{},
+	models.Link{},
+	models.ConnectivityReport{},
+	models.CriticalSegment{},
+	models.CriticalZone{},
+	models.CriticalityReport{},
+	models.ZoneCentrality{},
+	models.SegmentCentrality{},
+	models.CentralityReport{},
+	models.NetworkSize{},
+	models.HealthCheck{},
+	models.HealthReport{},
+	models.ImportZone{},
+	models.ImportConnection{},
+	models.ImportData{},
+	models.ImportIssue{},
+	models.ImportReport{},
+	models.Order{},
+	models.PlanRequest{},
+	models.PlannedStop{},
+	models.VehicleRoute{},
+	models.UnservedOrder{},
+	models.RoutePlan{},
+	models.Route{},
+	models.FlowResult{},
+	models.ZoneSegmentStats{},
+	models.SegmentQueryResult{},
+	models.SegmentRef{},
+	models.TrafficChange{},
+	models.SimulationRequest{},
+	models.ZoneDelta{},
+	models.SimulationResult{},
+	models.SnapshotZone{},
+	models.SnapshotSegment{},
+	models.SnapshotInfo{},
+	models.Snapshot{},
+	models.SnapshotRequest{},
+	models.RestoreResult{},
+	models.DispatchRequest{},
+	models.PositionReport{},
+	models.VehicleTracking{},
+	models.ZoneRecord{},
+	models.SegmentRecord{},
+	models.ValidationIssue{},
+	models.ValidationReport{},
+	models.Zone{},
+	models.DistributionCenter{},
+	models.Connection{},
+	models.SegmentUpdate{},
+	models.ZoneLocation{},
+	events.Event{},
+}
+
+// Spec arma la especificación completa de la API. Cada endpoint registrado en cmd/main.go debe
+// tener aquí su entrada, con los mismos parámetros, cuerpos y códigos de estado que su handler
+func Spec() *Document {
+	b := &builder{registry: NewRegistry(), paths: make(map[string]PathItem)}
+	for _, model := range modelTypes {
+		b.registry.Ref(model)
+	}
+	b.graph()
+	b.zones()
+	b.routes()
+	b.fleet()
+	b.snapshots()
+	b.admin()
+	b.operations()
+
+	return &Document{
+		OpenAPI: "3.0.3",
+		Info: Info{
+			Title:   "neo4j_delivery API",
+			Version: APIVersion,
+			Description: "API de la red de reparto: zonas, tramos, rutas, flota y administración de la red.\n\n" +
+				"Los errores se responden como texto plano con el mensaje. Cada operación indica en " +
+				"x-required-role el rol mínimo; los roles son viewer < dispatcher < admin y cada uno " +
+				"incluye los permisos de los anteriores.",
+		},
+		Tags: []Tag{
+			{Name: "graph", Description: "Grafo completo de la red y sus exportaciones"},
+			{Name: "zones", Description: "Zonas, accesibilidad y análisis de la red"},
+			{Name: "routes", Description: "Tramos, rutas y simulaciones"},
+			{Name: "fleet", Description: "Vehículos, conductores y seguimiento"},
+			{Name: "snapshots", Description: "Versiones guardadas de la red"},
+			{Name: "admin", Description: "Auditoría, validación e importación"},
+			{Name: "operations", Description: "Salud, métricas y documentación, sin autenticación"},
+		},
+		Paths: b.paths,
+		Components: Components{
+			Schemas: b.registry.Schemas(),
+			SecuritySchemes: map[string]SecurityScheme{
+				"apiKey": {
+					Type:        "apiKey",
+					Description: "Clave configurada en API_KEYS",
+					Name:        "X-API-Key",
+					In:          "header",
+				},
+				"bearerAuth": {
+					Type:         "http",
+					Description:  "JWT firmado con HS256 o RS256 con el claim role",
+					Scheme:       "bearer",
+					BearerFormat: "JWT",
+				},
+			},
+		},
+	}
+}
+
+type builder struct {
+	registry *Registry
+	paths    map[string]PathItem
+}
+
+// Registra la operación. Con role vacío la ruta no requiere autenticación; si no, se agregan el
+// esquema de seguridad y las respuestas 401 y 403 que produce auth.Require
+func (b *builder) add(method, path, tag, role string, op Operation) {
+	op.Tags = []string{tag}
+	op.OperationID = operationID(method, path)
+	if role != "" {
+		op.RequiredRole = role
+		op.Security = []map[string][]string{{"apiKey": {}}, {"bearerAuth": {}}}
+		op.Responses["401"] = errorResponse("Falta la credencial o no es válida")
+		op.Responses["403"] = errorResponse(fmt.Sprintf("Se requiere el rol %s", role))
+	}
+	if b.paths[path] == nil {
+		b.paths[path] = PathItem{}
+	}
+	b.paths[path][strings.ToLower(method)] = &op
+}
+
+// Identificador estable a partir del método y la ruta: GET /api/zones/locate -> getZonesLocate
+func operationID(method, path string) string {
+	id := strings.ToLower(method)
+	parts := strings.FieldsFunc(strings.TrimPrefix(path, "/api"), func(r rune) bool { return r == '/' || r == '.' })
+	for _, part := range parts {
+		id += strings.ToUpper(part[:1]) + part[1:]
+	}
+	return id
+}
+
+func (b *builder) ref(value interface{}) *Schema {
+	return b.registry.Ref(value)
+}
+
+func (b *builder) listOf(value interface{}) *Schema {
+	return &Schema{Type: "array", Items: b.ref(value)}
+}
+
+// Objeto {"items": [...]} con que responden los listados
+func (b *builder) items(value interface{}) *Schema {
+	return object(map[string]*Schema{"items": b.listOf(value)}, "items")
+}
+
+func object(properties map[string]*Schema, required ...string) *Schema {
+	return &Schema{Type: "object", Properties: properties, Required: required}
+}
+
+func stringSchema() *Schema  { return &Schema{Type: "string"} }
+func integerSchema() *Schema { return &Schema{Type: "integer"} }
+func numberSchema() *Schema  { return &Schema{Type: "number", Format: "double"} }
+func booleanSchema() *Schema { return &Schema{Type: "boolean"} }
+
+func stringList() *Schema {
+	return &Schema{Type: "array", Items: stringSchema()}
+}
+
+func enumSchema(values ...string) *Schema {
+	return &Schema{Type: "string", Enum: values}
+}
+
+func query(name string, schema *Schema, description string) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: schema}
+}
+
+func requiredQuery(name string, schema *Schema, description string) Parameter {
+	p := query(name, schema, description)
+	p.Required = true
+	return p
+}
+
+func jsonBody(schema *Schema, description string) *RequestBody {
+	return &RequestBody{
+		Description: description,
+		Required:    true,
+		Content:     map[string]MediaType{"application/json": {Schema: schema}},
+	}
+}
+
+func jsonResponse(description string, schema *Schema) Response {
+	return Response{Description: description, Content: map[string]MediaType{"application/json": {Schema: schema}}}
+}
+
+func contentResponse(description, contentType string, schema *Schema) Response {
+	return Response{Description: description, Content: map[string]MediaType{contentType: {Schema: schema}}}
+}
+
+// Los handlers responden los errores con http.Error, como texto plano
+func errorResponse(description string) Response {
+	return contentResponse(description, "text/plain", stringSchema())
+}
+
+const commaList = "Lista separada por comas"
+
+func (b *builder) graph() {
+	b.add(http.MethodGet, "/api/graph", "graph", auth.RoleViewer, Operation{
+		Summary: "Grafo de la red, completo o filtrado",
+		Description: "zone con hops y/o minutes limita el grafo a la vecindad de una zona. " +
+			"offset y limit paginan los nodos y los links siguen a la página.",
+		Parameters: []Parameter{
+			query("zone", stringSchema(), "Zona central de la vecindad"),
+			query("hops", integerSchema(), "Saltos máximos desde zone"),
+			query("minutes", numberSchema(), "Minutos máximos desde zone"),
+			query("tipo_zona", stringSchema(), commaList+" de tipos de zona"),
+			query("label", enumSchema("Zona", "CentroDistribucion"), "Etiqueta de los nodos"),
+			query("trafico", stringSchema(), commaList+" de niveles de tráfico de los tramos"),
+			query("accesible", booleanSchema(), "Solo tramos accesibles o inaccesibles"),
+			query("offset", integerSchema(), "Primer nodo de la página"),
+			query("limit", integerSchema(), "Nodos por página; 0 retorna todos"),
+		},
+		Responses: map[string]Response{
+			"200": jsonResponse("Nodos y links", b.ref(models.GraphData{})),
+			"400": errorResponse("Filtro inválido"),
+			"404": errorResponse("No existe la zona indicada"),
+			"500": errorResponse("Error al consultar Neo4j"),
+		},
+	})
+
+	b.add(http.MethodGet, "/api/graph/geojson", "graph", auth.RoleViewer, Operation{
+		Summary:     "Red en GeoJSON",
+		Description: "Con start y end incluye la ruta más corta como LineString. Las coordenadas van en orden [lng, lat].",
+		Parameters: []Parameter{
+			query("start", stringSchema(), "Zona de inicio de la ruta"),
+			query("end", stringSchema(), "Zona de destino de la ruta"),
+		},
+		Responses: map[string]Response{
+			"200": contentResponse("FeatureCollection con zonas, tramos y la ruta", "application/geo+json", b.ref(models.FeatureCollection{})),
+			"404": errorResponse("No existe la zona indicada"),
+			"500": errorResponse("Error al consultar Neo4j"),
+		},
+	})
+
+	b.add(http.MethodGet, "/api/graph/export", "graph", auth.RoleViewer, Operation{
+		Summary: "Exporta el grafo como DOT, GraphML o CSV",
+		Parameters: []Parameter{
+			requiredQuery("format", enumSchema(export.FormatDOT, export.FormatGraphML, export.FormatCSV), "Formato de salida"),
+			query("part", enumSchema(export.PartNodes, export.PartEdges), "Con format=csv, exporta los nodos o los tramos"),
+		},
+		Responses: map[string]Response{
+			"200": {
+				Description: "Grafo en el formato pedido",
+				Content: map[string]MediaType{
+					export.ContentTypes[export.FormatDOT]:     {Schema: stringSchema()},
+					export.ContentTypes[export.FormatGraphML]: {Schema: stringSchema()},
+					export.ContentTypes[export.FormatCSV]:     {Schema: stringSchema()},
+				},
+			},
+			"400": errorResponse("Formato desconocido"),
+			"500": errorResponse("Error al consultar Neo4j"),
+		},
+	})
+}
+
+func (b *builder) zones() {
+	message := object(map[string]*Schema{"message": stringSchema()}, "message")
+
+	b.add(http.MethodGet, "/api/zones", "zones", auth.RoleViewer, Operation{
+		Summary:   "Comprueba que el endpoint de zonas responde",
+		Responses: map[string]Response{"200": jsonResponse("Mensaje fijo", message)},
+	})
+
+	b.add(http.MethodGet, "/api/zones/dijkstra", "zones", auth.RoleViewer, Operation{
+		Summary: "Ruta más corta entre dos zonas",
+		Description: "Con version o at la ruta se calcula sobre la red guardada en ese snapshot y la " +
+			"respuesta incluye la versión usada. Si no hay ruta sobre la red actual responde el texto " +
+			"unreachable con estado 200.",
+		Parameters: []Parameter{
+			requiredQuery("start", stringSchema(), "Zona de inicio"),
+			requiredQuery("end", stringSchema(), "Zona de destino"),
+			query("algorithm", enumSchema("dijkstra", "astar"), "astar usa la búsqueda dirigida por coordenadas; por defecto Dijkstra"),
+			query("version", integerSchema(), "Versión del snapshot"),
+			query("at", &Schema{Type: "string", Format: "date-time"}, "Usa el snapshot vigente en ese instante (RFC 3339)"),
+		},
+		Responses: map[string]Response{
+			"200": {
+				Description: "Zonas de la ruta en orden y su duración en minutos, o unreachable",
+				Content: map[string]MediaType{
+					"application/json": {Schema: object(map[string]*Schema{
+						"items":   stringList(),
+						"minutes": numberSchema(),
+						"version": &Schema{Type: "integer", Description: "Solo con version o at"},
+					}, "items", "minutes")},
+					"text/plain": {Schema: enumSchema("unreachable")},
+				},
+			},
+			"400": errorResponse("version o at inválidos"),
+			"404": errorResponse("No existe el snapshot, o no hay ruta en él"),
+			"500": errorResponse("Error al consultar Neo4j"),
+		},
+	})
+
+	b.add(http.MethodGet, "/api/zones/accesible", "zones", auth.RoleViewer, Operation{
+		Summary: "Zonas accesibles desde una zona",
+		Description: "Sin direct separa las zonas en accesibles e inaccesibles desde start. Con direct " +
+			"retorna las rutas desde start que se recorren en menos de minutes, agrupadas por destino.",
+		Parameters: []Parameter{
+			requiredQuery("start", stringSchema(), "Zona de inicio"),
+			query("direct", stringSchema(), "Cualquier valor activa el modo de rutas directas"),
+			query("minutes", numberSchema(), "Tiempo máximo; requerido con direct"),
+		},
+		Responses: map[string]Response{
+			"200": jsonResponse("Zonas o rutas accesibles", &Schema{
+				Description: "{accesible, inaccesible} sin direct, o {from, to} con direct",
+				OneOf: []*Schema{
+					object(map[string]*Schema{"accesible": stringList(), "inaccesible": stringList()}, "accesible", "inaccesible"),
+					object(map[string]*Schema{
+						"from": stringSchema(),
+						"to":   {Type: "object", AdditionalProperties: b.listOf(models.Route{})},
+					}, "from", "to"),
+				},
+			}),
+			"400": errorResponse("minutes no es un número"),
+		},
+	})
+
+	b.add(http.MethodGet, "/api/zones/locate", "zones", auth.RoleViewer, Operation{
+		Summary: "Zona que contiene unas coordenadas, o la más cercana",
+		Parameters: []Parameter{
+			requiredQuery("lat", numberSchema(), "Latitud, entre -90 y 90"),
+			requiredQuery("lng", numberSchema(), "Longitud, entre -180 y 180"),
+		},
+		Responses: map[string]Response{
+			"200": jsonResponse("Zona encontrada", b.ref(models.ZoneLocation{})),
+			"400": errorResponse("Coordenadas inválidas"),
+			"404": errorResponse("Ningún polígono contiene el punto y no hay centroide a menos de LOCATE_MAX_KM"),
+			"500": errorResponse("Error al consultar Neo4j"),
+		},
+	})
+
+	b.add(http.MethodGet, "/api/zones/components", "zones", auth.RoleViewer, Operation{
+		Summary: "Componentes conexas, trampas de sentido único y zonas sin retorno al centro",
+		Responses: map[string]Response{
+			"200": jsonResponse("Reporte de conectividad", b.ref(models.ConnectivityReport{})),
+			"500": errorResponse("Error al consultar Neo4j"),
+		},
+	})
+
+	b.add(http.MethodGet, "/api/zones/centrality", "zones", auth.RoleViewer, Operation{
+		Summary: "Ranking de zonas y tramos por centralidad",
+		Parameters: []Parameter{
+			query("persist", booleanSchema(), "true guarda las métricas en Neo4j y requiere admin"),
+		},
+		Responses: map[string]Response{
+			"200": jsonResponse("Centralidad de zonas y tramos", b.ref(models.CentralityReport{})),
+			"500": errorResponse("Error al consultar Neo4j"),
+		},
+	})
+}
+
+func (b *builder) routes() {
+	b.add(http.MethodGet, "/api/route", "routes", auth.RoleViewer, Operation{
+		Summary:   "Comprueba que el endpoint de rutas responde",
+		Responses: map[string]Response{"200": jsonResponse("Mensaje fijo", object(map[string]*Schema{"message": stringSchema()}, "message"))},
+	})
+
+	b.add(http.MethodGet, "/api/route/hightraffic", "routes", auth.RoleViewer, Operation{
+		Summary:     "Tramos con tráfico alto",
+		Description: "Alias de /api/route/segments?trafico=alto.",
+		Responses: map[string]Response{
+			"200": jsonResponse("Tramos", b.items(models.Connection{})),
+			"500": errorResponse("Error al consultar Neo4j"),
+		},
+	})
+
+	b.add(http.MethodGet, "/api/route/segments", "routes", auth.RoleViewer, Operation{
+		Summary: "Consulta de tramos con filtros, orden, paginación y agregación por zona",
+		Parameters: []Parameter{
+			query("trafico", stringSchema(), commaList+" de niveles de tráfico"),
+			query("accesible", booleanSchema(), "Solo tramos accesibles o inaccesibles"),
+			query("min_capacidad", integerSchema(), "Capacidad mínima"),
+			query("max_capacidad", integerSchema(), "Capacidad máxima"),
+			query("min_tiempo", numberSchema(), "Minutos mínimos"),
+			query("max_tiempo", numberSchema(), "Minutos máximos"),
+			query("source", stringSchema(), "Zona de origen"),
+			query("target", stringSchema(), "Zona de destino"),
+			query("sort", enumSchema("source", "target", "tiempo", "capacidad", "trafico"), "Campo de orden"),
+			query("order", enumSchema("asc", "desc"), "Sentido del orden"),
+			query("offset", integerSchema(), "Primer tramo de la página"),
+			query("limit", integerSchema(), "Tramos por página; 0 retorna todos"),
+			query("group", enumSchema("source", "target"), "Agrega los tramos salientes (source) o entrantes (target) por zona"),
+		},
+		Responses: map[string]Response{
+			"200": jsonResponse("Tramos o agregados por zona", b.ref(models.SegmentQueryResult{})),
+			"400": errorResponse("Filtro inválido"),
+			"500": errorResponse("Error al consultar Neo4j"),
+		},
+	})
+
+	b.add(http.MethodPut, "/api/route/segment", "routes", auth.RoleDispatcher, Operation{
+		Summary: "Actualiza el tráfico, la accesibilidad o el tiempo de un tramo",
+		Parameters: []Parameter{
+			requiredQuery("source", stringSchema(), "Zona de origen"),
+			requiredQuery("target", stringSchema(), "Zona de destino"),
+		},
+		RequestBody: jsonBody(b.ref(models.SegmentUpdate{}), "Propiedades a cambiar; las omitidas no se modifican"),
+		Responses: map[string]Response{
+			"200": jsonResponse("Tramo actualizado", b.ref(models.Connection{})),
+			"400": errorResponse("Cuerpo inválido"),
+			"404": errorResponse("No existe el tramo"),
+			"405": errorResponse("Método distinto de PUT"),
+			"500": errorResponse("Error al escribir en Neo4j"),
+		},
+	})
+
+	b.add(http.MethodGet, "/api/route/critical", "routes", auth.RoleViewer, Operation{
+		Summary: "Tramos y zonas cuya caída aísla parte de la red",
+		Parameters: []Parameter{
+			query("rank", enumSchema("zones", "population"), "population ordena por habitantes aislados; por defecto por número de zonas"),
+		},
+		Responses: map[string]Response{
+			"200": jsonResponse("Elementos críticos", b.ref(models.CriticalityReport{})),
+			"500": errorResponse("Error al consultar Neo4j"),
+		},
+	})
+
+	b.add(http.MethodPost, "/api/route/simulate", "routes", auth.RoleViewer, Operation{
+		Summary:     "Simula cierres y cambios de tráfico sin modificar la red",
+		RequestBody: jsonBody(b.ref(models.SimulationRequest{}), "Tramos a cerrar y cambios de tráfico"),
+		Responses: map[string]Response{
+			"200": jsonResponse("Impacto de la simulación", b.ref(models.SimulationResult{})),
+			"400": errorResponse("Simulación inválida"),
+			"405": errorResponse("Método distinto de POST"),
+			"500": errorResponse("Error al consultar Neo4j"),
+		},
+	})
+
+	b.add(http.MethodGet, "/api/route/maxflow", "routes", auth.RoleViewer, Operation{
+		Summary: "Capacidad máxima entre dos zonas y los tramos que la limitan",
+		Parameters: []Parameter{
+			requiredQuery("from", stringSchema(), "Zona de origen"),
+			requiredQuery("to", stringSchema(), "Zona de destino"),
+		},
+		Responses: map[string]Response{
+			"200": jsonResponse("Flujo máximo", b.ref(models.FlowResult{})),
+			"404": errorResponse("No existe alguna de las zonas"),
+			"500": errorResponse("Error al consultar Neo4j"),
+		},
+	})
+
+	b.add(http.MethodPost, "/api/route/plan", "routes", auth.RoleViewer, Operation{
+		Summary:     "Planifica las rutas de la flota para un conjunto de pedidos",
+		RequestBody: jsonBody(b.ref(models.PlanRequest{}), "Pedidos y vehículos disponibles"),
+		Responses: map[string]Response{
+			"200": jsonResponse("Rutas por vehículo y pedidos sin atender", b.ref(models.RoutePlan{})),
+			"400": errorResponse("Plan inválido"),
+			"405": errorResponse("Método distinto de POST"),
+			"500": errorResponse("Error al consultar Neo4j"),
+		},
+	})
+}
+
+func (b *builder) fleet() {
+	b.crud("/api/fleet/vehicles", "vehículo", models.Vehiculo{})
+	b.crud("/api/fleet/drivers", "conductor", models.Conductor{})
+	// Solo los vehículos cuentan para la capacidad del centro
+	for _, method := range []string{"post", "put"} {
+		b.paths["/api/fleet/vehicles"][method].Responses["409"] = errorResponse("El centro no admite más vehículos activos")
+	}
+	b.paths["/api/fleet/drivers"]["post"].Responses["404"] = errorResponse("No existe el centro de distribución o el vehículo")
+	b.paths["/api/fleet/drivers"]["put"].Responses["404"] = errorResponse("No existe el conductor, el centro o el vehículo")
+
+	b.add(http.MethodPost, "/api/fleet/dispatch", "fleet", auth.RoleDispatcher, Operation{
+		Summary:     "Despacha un vehículo hacia una zona",
+		RequestBody: jsonBody(b.ref(models.DispatchRequest{}), "Vehículo y destino"),
+		Responses: map[string]Response{
+			"200": jsonResponse("Seguimiento del viaje", b.ref(models.VehicleTracking{})),
+			"400": errorResponse("Despacho inválido"),
+			"404": errorResponse("No existe el vehículo o la zona"),
+			"405": errorResponse("Método distinto de POST"),
+			"500": errorResponse("Error al escribir en Neo4j"),
+		},
+	})
+
+	b.add(http.MethodGet, "/api/fleet/position", "fleet", auth.RoleViewer, Operation{
+		Summary:    "Seguimiento de un vehículo despachado",
+		Parameters: []Parameter{requiredQuery("id", stringSchema(), "ID del vehículo")},
+		Responses: map[string]Response{
+			"200": jsonResponse("Seguimiento", b.ref(models.VehicleTracking{})),
+			"404": errorResponse("El vehículo no está en viaje"),
+			"500": errorResponse("Error al consultar Neo4j"),
+		},
+	})
+	b.add(http.MethodPost, "/api/fleet/position", "fleet", auth.RoleDispatcher, Operation{
+		Summary:     "Reporta la posición actual de un vehículo en viaje",
+		RequestBody: jsonBody(b.ref(models.PositionReport{}), "Zona alcanzada por el vehículo"),
+		Responses: map[string]Response{
+			"200": jsonResponse("Seguimiento actualizado", b.ref(models.VehicleTracking{})),
+			"400": errorResponse("Reporte inválido"),
+			"404": errorResponse("El vehículo no está en viaje"),
+			"500": errorResponse("Error al escribir en Neo4j"),
+		},
+	})
+
+	b.add(http.MethodGet, "/api/fleet/delayed", "fleet", auth.RoleViewer, Operation{
+		Summary:    "Vehículos con un retraso mayor al umbral",
+		Parameters: []Parameter{query("threshold", numberSchema(), "Minutos de retraso; por defecto 0")},
+		Responses: map[string]Response{
+			"200": jsonResponse("Vehículos retrasados", b.items(models.VehicleTracking{})),
+			"400": errorResponse("threshold no es un número"),
+			"500": errorResponse("Error al consultar Neo4j"),
+		},
+	})
+
+	b.add(http.MethodGet, "/api/events", "fleet", auth.RoleViewer, Operation{
+		Summary: "Stream SSE de cambios en la red y la flota",
+		Description: "Cada mensaje lleva event: <tipo> y data: <Event en JSON>. Cada 15 segundos se envía " +
+			"un comentario keep-alive. Como EventSource no puede enviar cabeceras, la clave o el JWT " +
+			"también se aceptan en access_token.",
+		Parameters: []Parameter{
+			query(auth.AccessTokenParam, stringSchema(), "Clave de API o JWT, si no se envían X-API-Key ni Authorization"),
+			query("zone", stringSchema(), commaList+" de zonas afectadas"),
+			query("type", stringSchema(), commaList+" de tipos: "+strings.Join([]string{
+				events.ZoneUpdated, events.ConnectionUpdated, events.TrafficChanged, events.ClosureStarted,
+				events.ClosureEnded, events.VehicleMoved, events.VehicleUpdated, events.NetworkRestored,
+			}, ", ")),
+		},
+		Responses: map[string]Response{
+			"200": contentResponse("Stream de eventos; el campo data de cada mensaje es un Event", "text/event-stream", stringSchema()),
+			"500": errorResponse("El servidor no soporta streaming"),
+		},
+	})
+}
+
+// Operaciones de /api/fleet/vehicles y /api/fleet/drivers: leer requiere viewer y escribir dispatcher
+func (b *builder) crud(path, name string, model interface{}) {
+	schema := b.ref(model)
+	id := requiredQuery("id", stringSchema(), "ID del "+name)
+
+	b.add(http.MethodGet, path, "fleet", auth.RoleViewer, Operation{
+		Summary: "Lista los registros o, con id, retorna uno (" + name + ")",
+		Parameters: []Parameter{
+			query("id", stringSchema(), "ID del "+name),
+			query("centro", stringSchema(), "Solo los del centro de distribución indicado"),
+		},
+		Responses: map[string]Response{
+			"200": jsonResponse("{items} sin id, o el registro con id", &Schema{
+				Description: "Sin id un objeto {items}; con id el " + name,
+				OneOf:       []*Schema{b.items(model), schema},
+			}),
+			"404": errorResponse("No existe el " + name),
+			"500": errorResponse("Error al consultar Neo4j"),
+		},
+	})
+	b.add(http.MethodPost, path, "fleet", auth.RoleDispatcher, Operation{
+		Summary:     "Crea un " + name,
+		RequestBody: jsonBody(schema, "Datos del "+name),
+		Responses: map[string]Response{
+			"200": jsonResponse("Registro creado", schema),
+			"400": errorResponse("Datos inválidos"),
+			"404": errorResponse("No existe el centro de distribución"),
+			"500": errorResponse("Error al escribir en Neo4j"),
+		},
+	})
+	b.add(http.MethodPut, path, "fleet", auth.RoleDispatcher, Operation{
+		Summary:     "Actualiza un " + name,
+		Parameters:  []Parameter{id},
+		RequestBody: jsonBody(schema, "Datos del "+name+"; el ID lo da el parámetro id"),
+		Responses: map[string]Response{
+			"200": jsonResponse("Registro actualizado", schema),
+			"400": errorResponse("Datos inválidos"),
+			"404": errorResponse("No existe el " + name + " o el centro"),
+			"500": errorResponse("Error al escribir en Neo4j"),
+		},
+	})
+	b.add(http.MethodDelete, path, "fleet", auth.RoleDispatcher, Operation{
+		Summary:    "Elimina un " + name,
+		Parameters: []Parameter{id},
+		Responses: map[string]Response{
+			"204": {Description: "Eliminado"},
+			"404": errorResponse("No existe el " + name),
+			"500": errorResponse("Error al escribir en Neo4j"),
+		},
+	})
+}
+
+func (b *builder) snapshots() {
+	b.add(http.MethodGet, "/api/snapshots", "snapshots", auth.RoleViewer, Operation{
+		Summary: "Lista las versiones guardadas o, con version o at, retorna una completa",
+		Parameters: []Parameter{
+			query("version", integerSchema(), "Versión del snapshot"),
+			query("at", &Schema{Type: "string", Format: "date-time"}, "Snapshot vigente en ese instante (RFC 3339)"),
+		},
+		Responses: map[string]Response{
+			"200": jsonResponse("{items} con las versiones, o el snapshot pedido", &Schema{
+				Description: "Sin version ni at un objeto {items: SnapshotInfo[]}; con ellos un Snapshot",
+				OneOf:       []*Schema{b.items(models.SnapshotInfo{}), b.ref(models.Snapshot{})},
+			}),
+			"400": errorResponse("version o at inválidos"),
+			"404": errorResponse("No existe el snapshot"),
+			"500": errorResponse("Error al consultar Neo4j"),
+		},
+	})
+	b.add(http.MethodPost, "/api/snapshots", "snapshots", auth.RoleAdmin, Operation{
+		Summary: "Guarda la red actual como una versión nueva",
+		RequestBody: &RequestBody{
+			Description: "Opcional",
+			Content:     map[string]MediaType{"application/json": {Schema: b.ref(models.SnapshotRequest{})}},
+		},
+		Responses: map[string]Response{
+			"201": jsonResponse("Versión creada", b.ref(models.SnapshotInfo{})),
+			"400": errorResponse("Cuerpo inválido"),
+			"500": errorResponse("Error al escribir en Neo4j"),
+		},
+	})
+
+	b.add(http.MethodPost, "/api/snapshots/restore", "snapshots", auth.RoleAdmin, Operation{
+		Summary:    "Restaura la red a una versión, guardando antes un snapshot de respaldo",
+		Parameters: []Parameter{requiredQuery("version", integerSchema(), "Versión a restaurar")},
+		Responses: map[string]Response{
+			"200": jsonResponse("Resultado de la restauración", b.ref(models.RestoreResult{})),
+			"400": errorResponse("version no es un entero positivo"),
+			"404": errorResponse("No existe el snapshot"),
+			"405": errorResponse("Método distinto de POST"),
+			"500": errorResponse("Error al escribir en Neo4j"),
+		},
+	})
+}
+
+func (b *builder) admin() {
+	b.add(http.MethodGet, "/api/audit", "admin", auth.RoleDispatcher, Operation{
+		Summary: "Historial de cambios de una zona o de un tramo, el más reciente primero",
+		Parameters: []Parameter{
+			query("zone", stringSchema(), "Zona; si no se indica se requieren source y target"),
+			query("source", stringSchema(), "Zona de origen del tramo"),
+			query("target", stringSchema(), "Zona de destino del tramo"),
+			query("limit", integerSchema(), "Máximo de entradas; 0 usa el límite por defecto"),
+		},
+		Responses: map[string]Response{
+			"200": jsonResponse("Entradas del historial", b.items(models.AuditEntry{})),
+			"400": errorResponse("Faltan zone o source y target, o limit es inválido"),
+			"500": errorResponse("Error al consultar Neo4j"),
+		},
+	})
+
+	b.add(http.MethodGet, "/api/admin/validate", "admin", auth.RoleAdmin, Operation{
+		Summary: "Reporte de integridad de la red",
+		Description: "Lazos, tiempos inválidos, propiedades faltantes, pares con tiempos distintos, zonas " +
+			"aisladas o inalcanzables desde los centros.",
+		Responses: map[string]Response{
+			"200": jsonResponse("Problemas encontrados", b.ref(models.ValidationReport{})),
+			"500": errorResponse("Error al consultar Neo4j"),
+		},
+	})
+
+	b.add(http.MethodPost, "/api/admin/import", "admin", auth.RoleAdmin, Operation{
+		Summary: "Importación masiva de zonas y tramos",
+		Parameters: []Parameter{
+			query("format", enumSchema("json", "csv"), "csv lee un formulario multipart con los archivos zones y connections"),
+			query("dry_run", booleanSchema(), "Solo valida y reporta"),
+			query("strict", booleanSchema(), "Rechaza también advertencias de integridad nuevas"),
+		},
+		RequestBody: &RequestBody{
+			Required: true,
+			Content: map[string]MediaType{
+				"application/json": {Schema: b.ref(models.ImportData{})},
+				"multipart/form-data": {Schema: object(map[string]*Schema{
+					"zones":       {Type: "string", Format: "binary"},
+					"connections": {Type: "string", Format: "binary"},
+				})},
+			},
+		},
+		Responses: map[string]Response{
+			"200": jsonResponse("Importación aplicada o validada", b.ref(models.ImportReport{})),
+			"400": errorResponse("Archivo inválido"),
+			"405": errorResponse("Método distinto de POST"),
+			"422": jsonResponse("Hay errores; no se importó nada", b.ref(models.ImportReport{})),
+			"500": errorResponse("Error al escribir en Neo4j"),
+		},
+	})
+}
+
+func (b *builder) operations() {
+	health := b.ref(models.HealthReport{})
+	b.add(http.MethodGet, "/healthz", "operations", "", Operation{
+		Summary:   "El proceso está vivo",
+		Responses: map[string]Response{"200": jsonResponse("Siempre up", health)},
+	})
+	b.add(http.MethodGet, "/readyz", "operations", "", Operation{
+		Summary: "Neo4j, las restricciones del esquema, los datos iniciales y el grafo en caché están listos",
+		Responses: map[string]Response{
+			"200": jsonResponse("Todas las comprobaciones pasan", health),
+			"503": jsonResponse("Alguna comprobación falló", health),
+		},
+	})
+	b.add(http.MethodGet, "/metrics", "operations", "", Operation{
+		Summary: "Métricas en formato de texto de Prometheus",
+		Responses: map[string]Response{
+			"200": contentResponse("Métricas", "text/plain; version=0.0.4", stringSchema()),
+		},
+	})
+	b.add(http.MethodGet, "/api/openapi.json", "operations", "", Operation{
+		Summary:   "Esta especificación",
+		Responses: map[string]Response{"200": jsonResponse("Documento OpenAPI 3.0", &Schema{Type: "object"})},
+	})
+	b.add(http.MethodGet, "/api/docs", "operations", "", Operation{
+		Summary:   "Página de documentación generada a partir de esta especificación",
+		Responses: map[string]Response{"200": contentResponse("Página HTML", "text/html", stringSchema())},
+	})
+}
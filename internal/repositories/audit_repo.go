@@ -0,0 +1,150 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"neo4j_delivery/internal/models"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Cantidad de entradas de historial retornadas si no se indica límite
+const DefaultAuditLimit = 100
+
+type AuditRepository struct {
+	Driver neo4j.Driver
+}
+
+func NewAuditRepository(driver neo4j.Driver) *AuditRepository {
+	return &AuditRepository{Driver: driver}
+}
+
+// Guarda las entradas como nodos Auditoria; antes y despues se guardan como JSON
+func (r *AuditRepository) Save(ctx context.Context, entries []models.AuditEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	query := `UNWIND $rows AS row
+	CREATE (:Auditoria {
+		id: randomUUID(),
+		actor: row.actor,
+		rol: row.rol,
+		accion: row.accion,
+		entidad: row.entidad,
+		zona: row.zona,
+		source: row.source,
+		target: row.target,
+		timestamp: row.timestamp,
+		antes: row.antes,
+		despues: row.despues
+	})`
+
+	rows := make([]map[string]interface{}, 0, len(entries))
+	for _, entry := range entries {
+		row := map[string]interface{}{
+			"actor":     entry.Actor,
+			"rol":       entry.Role,
+			"accion":    entry.Action,
+			"entidad":   entry.Entity,
+			"zona":      nil,
+			"source":    nil,
+			"target":    nil,
+			"timestamp": entry.Timestamp,
+			"antes":     string(entry.Before),
+			"despues":   string(entry.After),
+		}
+		if entry.Zone != "" {
+			row["zona"] = entry.Zone
+		}
+		if entry.Source != "" {
+			row["source"] = entry.Source
+			row["target"] = entry.Target
+		}
+		rows = append(rows, row)
+	}
+
+	if err := writeBatches(ctx, r.Driver, "AuditRepository.Save", query, rows, DefaultBatchSize, nil); err != nil {
+		return fmt.Errorf("error saving audit entries: %w", err)
+	}
+	return nil
+}
+
+// Historial más reciente primero
+func (r *AuditRepository) FindHistory(ctx context.Context, query models.AuditQuery) ([]models.AuditEntry, error) {
+	cypher := `MATCH (a:Auditoria)
+	WHERE a.entidad = 'network'
+	OR (($zone IS NULL OR a.zona = $zone OR a.source = $zone OR a.target = $zone)
+	AND ($source IS NULL OR a.source = $source)
+	AND ($target IS NULL OR a.target = $target))
+	RETURN a.id AS id,
+	a.actor AS actor,
+	a.rol AS rol,
+	a.accion AS accion,
+	a.entidad AS entidad,
+	a.zona AS zona,
+	a.source AS source,
+	a.target AS target,
+	a.timestamp AS timestamp,
+	a.antes AS antes,
+	a.despues AS despues
+	ORDER BY a.timestamp DESC
+	LIMIT $limit`
+
+	params := map[string]interface{}{"zone": nil, "source": nil, "target": nil, "limit": query.Limit}
+	if query.Zone != "" {
+		params["zone"] = query.Zone
+	}
+	if query.Source != "" {
+		params["source"] = query.Source
+	}
+	if query.Target != "" {
+		params["target"] = query.Target
+	}
+	if query.Limit <= 0 {
+		params["limit"] = DefaultAuditLimit
+	}
+
+	session := newSession(ctx, r.Driver, "AuditRepository.FindHistory")
+	defer session.Close()
+
+	t, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		result, err := tx.Run(cypher, params)
+		if err != nil {
+			return nil, err
+		}
+		entries := []models.AuditEntry{}
+
+		for result.Next() {
+			data := result.Record().AsMap()
+			entry := models.AuditEntry{}
+			entry.ID, _ = data["id"].(string)
+			entry.Actor, _ = data["actor"].(string)
+			entry.Role, _ = data["rol"].(string)
+			entry.Action, _ = data["accion"].(string)
+			entry.Entity, _ = data["entidad"].(string)
+			entry.Zone, _ = data["zona"].(string)
+			entry.Source, _ = data["source"].(string)
+			entry.Target, _ = data["target"].(string)
+			entry.Timestamp, _ = data["timestamp"].(time.Time)
+			entry.Before = rawJSON(data["antes"])
+			entry.After = rawJSON(data["despues"])
+			entries = append(entries, entry)
+		}
+		return entries, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching audit history: %w", err)
+	}
+	return t.([]models.AuditEntry), nil
+}
+
+func rawJSON(value any) json.RawMessage {
+	text, ok := value.(string)
+	if !ok || text == "" || !json.Valid([]byte(text)) {
+		return json.RawMessage("null")
+	}
+	return json.RawMessage(text)
+}
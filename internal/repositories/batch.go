@@ -0,0 +1,50 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Tamaño de lote por defecto para las escrituras masivas
+const DefaultBatchSize = 500
+
+// Ejecuta query con UNWIND $rows en lotes de batchSize filas, una transacción por lote.
+// Si collect no es nil recibe cada registro retornado por la consulta. method es el método del
+// repositorio que hace la carga, para las métricas y las trazas
+func writeBatches(ctx context.Context, driver neo4j.Driver, method, query string, rows []map[string]interface{}, batchSize int, collect func(map[string]any)) error {
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	session := newSession(ctx, driver, method)
+	defer session.Close()
+
+	for start := 0; start < len(rows); start += batchSize {
+		end := start + batchSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+		batch := rows[start:end]
+		_, err := session.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+			result, err := tx.Run(query, map[string]interface{}{"rows": batch})
+			if err != nil {
+				return nil, err
+			}
+			records, err := result.Collect()
+			if err != nil {
+				return nil, err
+			}
+			if collect != nil {
+				for _, record := range records {
+					collect(record.AsMap())
+				}
+			}
+			return nil, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,352 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"neo4j_delivery/internal/models"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+var (
+	ErrNotFound         = errors.New("not found")
+	ErrCapacityExceeded = errors.New("distribution center vehicle capacity exceeded")
+)
+
+type FleetRepository struct {
+	Driver neo4j.Driver
+}
+
+func NewFleetRepository(driver neo4j.Driver) *FleetRepository {
+	return &FleetRepository{Driver: driver}
+}
+
+const vehicleReturn = `RETURN v.id AS id,
+	v.placa AS placa,
+	v.tipo AS tipo,
+	v.capacidad_carga AS capacidad_carga,
+	v.estado AS estado,
+	c.nombre AS centro`
+
+const driverReturn = `RETURN d.id AS id,
+	d.nombre AS nombre,
+	d.licencia AS licencia,
+	d.disponible AS disponible,
+	d.turno_inicio AS turno_inicio,
+	d.turno_fin AS turno_fin,
+	c.nombre AS centro,
+	v.id AS vehiculo`
+
+func vehicleFromRecord(data map[string]any) models.Vehiculo {
+	vehicle := models.Vehiculo{}
+	vehicle.ID, _ = data["id"].(string)
+	vehicle.Placa, _ = data["placa"].(string)
+	vehicle.Tipo, _ = data["tipo"].(string)
+	vehicle.Estado, _ = data["estado"].(string)
+	vehicle.Centro, _ = data["centro"].(string)
+	if val, ok := data["capacidad_carga"].(float64); ok {
+		vehicle.CapacidadCarga = val
+	} else if val, ok := data["capacidad_carga"].(int64); ok {
+		vehicle.CapacidadCarga = float64(val)
+	}
+	return vehicle
+}
+
+func driverFromRecord(data map[string]any) models.Conductor {
+	driver := models.Conductor{}
+	driver.ID, _ = data["id"].(string)
+	driver.Nombre, _ = data["nombre"].(string)
+	driver.Licencia, _ = data["licencia"].(string)
+	driver.Disponible, _ = data["disponible"].(bool)
+	driver.TurnoInicio, _ = data["turno_inicio"].(string)
+	driver.TurnoFin, _ = data["turno_fin"].(string)
+	driver.Centro, _ = data["centro"].(string)
+	driver.Vehiculo, _ = data["vehiculo"].(string)
+	return driver
+}
+
+// Verifica dentro de la transacción que el centro no tenga más vehículos activos que su capacidad.
+// Si se excede, el error provoca el rollback de la escritura que se acaba de hacer
+func checkCenterCapacity(tx neo4j.Transaction, center string) error {
+	result, err := tx.Run(`MATCH (c:CentroDistribucion {nombre: $centro})
+	OPTIONAL MATCH (c)<-[:PERTENECE_A]-(v:Vehiculo)
+	WHERE v.estado <> $inactivo
+	RETURN c.capacidad_vehiculos AS capacidad, count(v) AS activos`,
+		map[string]interface{}{"centro": center, "inactivo": models.VehiculoInactivo})
+	if err != nil {
+		return err
+	}
+	record, err := result.Single()
+	if err != nil {
+		return fmt.Errorf("%w: distribution center %q", ErrNotFound, center)
+	}
+	data := record.AsMap()
+	capacity, ok := data["capacidad"].(int64)
+	if !ok {
+		return nil
+	}
+	if active := data["activos"].(int64); active > capacity {
+		return fmt.Errorf("%w: %q allows %d active vehicles, would have %d", ErrCapacityExceeded, center, capacity, active)
+	}
+	return nil
+}
+
+func (r *FleetRepository) FindVehicles(ctx context.Context, center string) ([]models.Vehiculo, error) {
+	query := `MATCH (v:Vehiculo)-[:PERTENECE_A]->(c:CentroDistribucion)
+	WHERE $centro = '' OR c.nombre = $centro
+	` + vehicleReturn + `
+	ORDER BY c.nombre, v.placa`
+
+	session := newSession(ctx, r.Driver, "FleetRepository.FindVehicles")
+	defer session.Close()
+
+	t, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		result, err := tx.Run(query, map[string]interface{}{"centro": center})
+		if err != nil {
+			return nil, err
+		}
+		vehicles := []models.Vehiculo{}
+		for result.Next() {
+			vehicles = append(vehicles, vehicleFromRecord(result.Record().AsMap()))
+		}
+		return vehicles, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching vehicles: %w", err)
+	}
+	return t.([]models.Vehiculo), nil
+}
+
+func (r *FleetRepository) FindVehicle(ctx context.Context, id string) (models.Vehiculo, error) {
+	query := `MATCH (v:Vehiculo {id: $id})-[:PERTENECE_A]->(c:CentroDistribucion)
+	` + vehicleReturn
+
+	session := newSession(ctx, r.Driver, "FleetRepository.FindVehicle")
+	defer session.Close()
+
+	t, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		result, err := tx.Run(query, map[string]interface{}{"id": id})
+		if err != nil {
+			return nil, err
+		}
+		if !result.Next() {
+			return nil, fmt.Errorf("%w: vehicle %q", ErrNotFound, id)
+		}
+		return vehicleFromRecord(result.Record().AsMap()), nil
+	})
+	if err != nil {
+		return models.Vehiculo{}, err
+	}
+	return t.(models.Vehiculo), nil
+}
+
+func (r *FleetRepository) CreateVehicle(ctx context.Context, vehicle models.Vehiculo) (models.Vehiculo, error) {
+	query := `MATCH (c:CentroDistribucion {nombre: $centro})
+	CREATE (v:Vehiculo {id: randomUUID(), placa: $placa, tipo: $tipo, capacidad_carga: $capacidad_carga, estado: $estado})
+	CREATE (v)-[:PERTENECE_A]->(c)
+	` + vehicleReturn
+
+	return r.writeVehicle(ctx, "FleetRepository.CreateVehicle", query, vehicle)
+}
+
+func (r *FleetRepository) UpdateVehicle(ctx context.Context, vehicle models.Vehiculo) (models.Vehiculo, error) {
+	query := `MATCH (v:Vehiculo {id: $id})-[p:PERTENECE_A]->(:CentroDistribucion)
+	MATCH (c:CentroDistribucion {nombre: $centro})
+	SET v.placa = $placa, v.tipo = $tipo, v.capacidad_carga = $capacidad_carga, v.estado = $estado
+	DELETE p
+	CREATE (v)-[:PERTENECE_A]->(c)
+	` + vehicleReturn
+
+	return r.writeVehicle(ctx, "FleetRepository.UpdateVehicle", query, vehicle)
+}
+
+func (r *FleetRepository) writeVehicle(ctx context.Context, method, query string, vehicle models.Vehiculo) (models.Vehiculo, error) {
+	params := map[string]interface{}{
+		"id":              vehicle.ID,
+		"placa":           vehicle.Placa,
+		"tipo":            vehicle.Tipo,
+		"capacidad_carga": vehicle.CapacidadCarga,
+		"estado":          vehicle.Estado,
+		"centro":          vehicle.Centro,
+	}
+
+	session := newSession(ctx, r.Driver, method)
+	defer session.Close()
+
+	t, err := session.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		result, err := tx.Run(query, params)
+		if err != nil {
+			return nil, err
+		}
+		if !result.Next() {
+			return nil, fmt.Errorf("%w: vehicle %q or center %q", ErrNotFound, vehicle.ID, vehicle.Centro)
+		}
+		saved := vehicleFromRecord(result.Record().AsMap())
+		if err := checkCenterCapacity(tx, saved.Centro); err != nil {
+			return nil, err
+		}
+		return saved, nil
+	})
+	if err != nil {
+		return models.Vehiculo{}, err
+	}
+	return t.(models.Vehiculo), nil
+}
+
+func (r *FleetRepository) DeleteVehicle(ctx context.Context, id string) error {
+	return r.deleteNode(ctx, "FleetRepository.DeleteVehicle", `MATCH (v:Vehiculo {id: $id}) DETACH DELETE v RETURN count(*) AS deleted`, id, "vehicle")
+}
+
+func (r *FleetRepository) FindDrivers(ctx context.Context, center string) ([]models.Conductor, error) {
+	query := `MATCH (d:Conductor)-[:PERTENECE_A]->(c:CentroDistribucion)
+	WHERE $centro = '' OR c.nombre = $centro
+	OPTIONAL MATCH (d)-[:CONDUCE]->(v:Vehiculo)
+	` + driverReturn + `
+	ORDER BY c.nombre, d.nombre`
+
+	session := newSession(ctx, r.Driver, "FleetRepository.FindDrivers")
+	defer session.Close()
+
+	t, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		result, err := tx.Run(query, map[string]interface{}{"centro": center})
+		if err != nil {
+			return nil, err
+		}
+		drivers := []models.Conductor{}
+		for result.Next() {
+			drivers = append(drivers, driverFromRecord(result.Record().AsMap()))
+		}
+		return drivers, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching drivers: %w", err)
+	}
+	return t.([]models.Conductor), nil
+}
+
+func (r *FleetRepository) FindDriver(ctx context.Context, id string) (models.Conductor, error) {
+	query := `MATCH (d:Conductor {id: $id})-[:PERTENECE_A]->(c:CentroDistribucion)
+	OPTIONAL MATCH (d)-[:CONDUCE]->(v:Vehiculo)
+	` + driverReturn
+
+	session := newSession(ctx, r.Driver, "FleetRepository.FindDriver")
+	defer session.Close()
+
+	t, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		result, err := tx.Run(query, map[string]interface{}{"id": id})
+		if err != nil {
+			return nil, err
+		}
+		if !result.Next() {
+			return nil, fmt.Errorf("%w: driver %q", ErrNotFound, id)
+		}
+		return driverFromRecord(result.Record().AsMap()), nil
+	})
+	if err != nil {
+		return models.Conductor{}, err
+	}
+	return t.(models.Conductor), nil
+}
+
+func (r *FleetRepository) CreateDriver(ctx context.Context, driver models.Conductor) (models.Conductor, error) {
+	query := `MATCH (c:CentroDistribucion {nombre: $centro})
+	CREATE (d:Conductor {id: randomUUID(), nombre: $nombre, licencia: $licencia, disponible: $disponible,
+		turno_inicio: $turno_inicio, turno_fin: $turno_fin})
+	CREATE (d)-[:PERTENECE_A]->(c)
+	WITH d, c
+	OPTIONAL MATCH (v:Vehiculo {id: $vehiculo})
+	FOREACH (_ IN CASE WHEN v IS NULL THEN [] ELSE [1] END | CREATE (d)-[:CONDUCE]->(v))
+	` + driverReturn
+
+	return r.writeDriver(ctx, "FleetRepository.CreateDriver", query, driver)
+}
+
+func (r *FleetRepository) UpdateDriver(ctx context.Context, driver models.Conductor) (models.Conductor, error) {
+	query := `MATCH (d:Conductor {id: $id})-[p:PERTENECE_A]->(:CentroDistribucion)
+	MATCH (c:CentroDistribucion {nombre: $centro})
+	SET d.nombre = $nombre, d.licencia = $licencia, d.disponible = $disponible,
+		d.turno_inicio = $turno_inicio, d.turno_fin = $turno_fin
+	DELETE p
+	CREATE (d)-[:PERTENECE_A]->(c)
+	WITH d, c
+	OPTIONAL MATCH (d)-[old:CONDUCE]->(:Vehiculo)
+	DELETE old
+	WITH DISTINCT d, c
+	OPTIONAL MATCH (v:Vehiculo {id: $vehiculo})
+	FOREACH (_ IN CASE WHEN v IS NULL THEN [] ELSE [1] END | CREATE (d)-[:CONDUCE]->(v))
+	` + driverReturn
+
+	return r.writeDriver(ctx, "FleetRepository.UpdateDriver", query, driver)
+}
+
+func (r *FleetRepository) writeDriver(ctx context.Context, method, query string, driver models.Conductor) (models.Conductor, error) {
+	params := map[string]interface{}{
+		"id":           driver.ID,
+		"nombre":       driver.Nombre,
+		"licencia":     driver.Licencia,
+		"disponible":   driver.Disponible,
+		"turno_inicio": driver.TurnoInicio,
+		"turno_fin":    driver.TurnoFin,
+		"centro":       driver.Centro,
+		"vehiculo":     driver.Vehiculo,
+	}
+
+	session := newSession(ctx, r.Driver, method)
+	defer session.Close()
+
+	t, err := session.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		// El OPTIONAL MATCH de la consulta ignoraría un vehículo inexistente; se comprueba antes
+		// de escribir para no dejar al conductor sin su vehículo anterior
+		if driver.Vehiculo != "" {
+			result, err := tx.Run(`MATCH (v:Vehiculo {id: $vehiculo}) RETURN count(v) AS found`, params)
+			if err != nil {
+				return nil, err
+			}
+			record, err := result.Single()
+			if err != nil {
+				return nil, err
+			}
+			if found, _ := record.Values[0].(int64); found == 0 {
+				return nil, fmt.Errorf("%w: vehicle %q", ErrNotFound, driver.Vehiculo)
+			}
+		}
+		result, err := tx.Run(query, params)
+		if err != nil {
+			return nil, err
+		}
+		if !result.Next() {
+			return nil, fmt.Errorf("%w: driver %q or center %q", ErrNotFound, driver.ID, driver.Centro)
+		}
+		return driverFromRecord(result.Record().AsMap()), nil
+	})
+	if err != nil {
+		return models.Conductor{}, err
+	}
+	return t.(models.Conductor), nil
+}
+
+func (r *FleetRepository) DeleteDriver(ctx context.Context, id string) error {
+	return r.deleteNode(ctx, "FleetRepository.DeleteDriver", `MATCH (d:Conductor {id: $id}) DETACH DELETE d RETURN count(*) AS deleted`, id, "driver")
+}
+
+func (r *FleetRepository) deleteNode(ctx context.Context, method, query, id, kind string) error {
+	session := newSession(ctx, r.Driver, method)
+	defer session.Close()
+
+	_, err := session.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		result, err := tx.Run(query, map[string]interface{}{"id": id})
+		if err != nil {
+			return nil, err
+		}
+		record, err := result.Single()
+		if err != nil {
+			return nil, err
+		}
+		if deleted, _ := record.AsMap()["deleted"].(int64); deleted == 0 {
+			return nil, fmt.Errorf("%w: %s %q", ErrNotFound, kind, id)
+		}
+		return nil, nil
+	})
+	return err
+}
@@ -1,6 +1,8 @@
 package repositories
 
 import (
+	"context"
+	"fmt"
 	"neo4j_delivery/internal/models"
 
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
@@ -14,19 +16,215 @@ func NewRouteRepository(driver neo4j.Driver) *RouteRepository {
 	return &RouteRepository{Driver: driver}
 }
 
-func (r *RouteRepository) GetHighTrafficEdges() []models.Connection {
+// Expresión de ORDER BY de cada criterio de orden aceptado en SegmentQuery.SortBy
+var segmentSortKeys = map[string]string{
+	"source":    "n.nombre",
+	"target":    "y.nombre",
+	"tiempo":    "z.tiempo_minutos",
+	"capacidad": "z.capacidad",
+	"trafico":   "CASE z.trafico_actual WHEN 'bajo' THEN 0 WHEN 'medio' THEN 1 WHEN 'alto' THEN 2 ELSE 3 END",
+}
+
+// Cláusula MATCH/WHERE y parámetros comunes a FindSegments y AggregateSegments
+func segmentFilter(query models.SegmentQuery) (string, map[string]interface{}) {
+	match := `MATCH (n)-[z:CONECTA]->(y)
+	WHERE ($traficos IS NULL OR z.trafico_actual IN $traficos)
+	AND ($accesible IS NULL OR coalesce(z.accesible, true) = $accesible)
+	AND ($min_capacidad IS NULL OR z.capacidad >= $min_capacidad)
+	AND ($max_capacidad IS NULL OR z.capacidad <= $max_capacidad)
+	AND ($min_tiempo IS NULL OR z.tiempo_minutos >= $min_tiempo)
+	AND ($max_tiempo IS NULL OR z.tiempo_minutos <= $max_tiempo)
+	AND ($source IS NULL OR n.nombre = $source)
+	AND ($target IS NULL OR y.nombre = $target)`
+
+	params := map[string]interface{}{
+		"traficos":      nil,
+		"accesible":     nil,
+		"min_capacidad": nil,
+		"max_capacidad": nil,
+		"min_tiempo":    nil,
+		"max_tiempo":    nil,
+		"source":        nil,
+		"target":        nil,
+		"offset":        query.Offset,
+		"limit":         query.Limit,
+		"group":         query.GroupBy,
+	}
+	if len(query.Trafico) > 0 {
+		params["traficos"] = query.Trafico
+	}
+	if query.Accesible != nil {
+		params["accesible"] = *query.Accesible
+	}
+	if query.MinCapacidad != nil {
+		params["min_capacidad"] = *query.MinCapacidad
+	}
+	if query.MaxCapacidad != nil {
+		params["max_capacidad"] = *query.MaxCapacidad
+	}
+	if query.MinTiempo != nil {
+		params["min_tiempo"] = *query.MinTiempo
+	}
+	if query.MaxTiempo != nil {
+		params["max_tiempo"] = *query.MaxTiempo
+	}
+	if query.Source != "" {
+		params["source"] = query.Source
+	}
+	if query.Target != "" {
+		params["target"] = query.Target
+	}
+	return match, params
+}
+
+// Retorna la página de tramos que cumplen los filtros y el total sin paginar
+func (r *RouteRepository) FindSegments(ctx context.Context, query models.SegmentQuery) ([]models.Connection, int, error) {
+	match, params := segmentFilter(query)
+
+	order := "n.nombre, y.nombre"
+	if key, ok := segmentSortKeys[query.SortBy]; ok {
+		order = key
+		if query.Desc {
+			order += " DESC"
+		}
+		order += ", n.nombre, y.nombre"
+	}
+	countQuery := match + `
+	RETURN count(z) AS total`
+	itemsQuery := match + `
+	RETURN n.nombre AS source,
+	y.nombre AS target,
+	z.capacidad AS capacidad,
+	z.trafico_actual AS traffic,
+	z.tiempo_minutos AS tiempo,
+	z.accesible AS accesible
+	ORDER BY ` + order + `
+	SKIP $offset`
+	if query.Limit > 0 {
+		itemsQuery += `
+	LIMIT $limit`
+	}
+
+	session := newSession(ctx, r.Driver, "RouteRepository.FindSegments")
+	defer session.Close()
+
+	var total int64
+	t, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		result, err := tx.Run(countQuery, params)
+		if err != nil {
+			return nil, err
+		}
+		record, err := result.Single()
+		if err != nil {
+			return nil, err
+		}
+		total, _ = record.Values[0].(int64)
+
+		result, err = tx.Run(itemsQuery, params)
+		if err != nil {
+			return nil, err
+		}
+		edges := []models.Connection{}
+
+		for result.Next() {
+			edges = append(edges, connectionFromRecord(result.Record().AsMap()))
+		}
+		return edges, nil
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("error fetching segments: %w", err)
+	}
+	return t.([]models.Connection), int(total), nil
+}
+
+// Agrega los tramos que cumplen los filtros por zona de origen (o de destino con GroupBy 'target')
+func (r *RouteRepository) AggregateSegments(ctx context.Context, query models.SegmentQuery) ([]models.ZoneSegmentStats, error) {
+	match, params := segmentFilter(query)
+	aggregateQuery := match + `
+	WITH CASE $group WHEN 'target' THEN y.nombre ELSE n.nombre END AS zone, z
+	RETURN zone,
+	count(z) AS segments,
+	sum(CASE WHEN z.trafico_actual = 'alto' THEN 1 ELSE 0 END) AS congested,
+	sum(CASE WHEN coalesce(z.accesible, true) THEN 0 ELSE 1 END) AS closed,
+	avg(z.tiempo_minutos) AS avg_tiempo,
+	sum(coalesce(z.capacidad, 0)) AS capacidad
+	ORDER BY congested DESC, segments DESC, zone`
+
+	session := newSession(ctx, r.Driver, "RouteRepository.AggregateSegments")
+	defer session.Close()
+
+	t, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		result, err := tx.Run(aggregateQuery, params)
+		if err != nil {
+			return nil, err
+		}
+		stats := []models.ZoneSegmentStats{}
+
+		for result.Next() {
+			data := result.Record().AsMap()
+			stat := models.ZoneSegmentStats{}
+			stat.Zone, _ = data["zone"].(string)
+			if val, ok := data["segments"].(int64); ok {
+				stat.Segments = int(val)
+			}
+			if val, ok := data["congested"].(int64); ok {
+				stat.Congested = int(val)
+			}
+			if val, ok := data["closed"].(int64); ok {
+				stat.Closed = int(val)
+			}
+			if val, ok := data["avg_tiempo"].(float64); ok {
+				stat.AvgTiempo = val
+			}
+			if val, ok := data["capacidad"].(int64); ok {
+				stat.TotalCapacidad = int(val)
+			}
+			stats = append(stats, stat)
+		}
+		return stats, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error aggregating segments: %w", err)
+	}
+	return t.([]models.ZoneSegmentStats), nil
+}
+
+// Convierte una fila con source, target, capacidad, traffic, tiempo y accesible en una conexión
+func connectionFromRecord(data map[string]any) models.Connection {
+	connection := models.Connection{Direccion: "?", Accesible: true}
+	if val, ok := data["source"].(string); ok {
+		connection.Source = val
+	}
+	if val, ok := data["target"].(string); ok {
+		connection.Target = val
+	}
+	if val, ok := data["tiempo"].(int64); ok {
+		connection.Tiempo = int(val)
+	}
+	if val, ok := data["capacidad"].(int64); ok {
+		connection.Capacidad = int(val)
+	}
+	if val, ok := data["traffic"].(string); ok {
+		connection.Trafico = val
+	}
+	if val, ok := data["accesible"].(bool); ok {
+		connection.Accesible = val
+	}
+	return connection
+}
+
+func (r *RouteRepository) GetAllConnections(ctx context.Context) ([]models.Connection, error) {
 
 	query := `MATCH (n)-[z:CONECTA]->(y)
-	WHERE z.trafico_actual='alto'
-	RETURN ID(z) AS id,
-	n.nombre AS source,
+	RETURN n.nombre AS source,
 	y.nombre AS target,
 	z.capacidad AS capacidad,
 	z.trafico_actual AS traffic,
 	z.tiempo_minutos AS tiempo,
-	z.accesible AS accesible`
+	z.accesible AS accesible
+	ORDER BY source, target`
 
-	session := r.Driver.NewSession(neo4j.SessionConfig{})
+	session := newSession(ctx, r.Driver, "RouteRepository.GetAllConnections")
 	defer session.Close()
 
 	t, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
@@ -37,21 +235,225 @@ func (r *RouteRepository) GetHighTrafficEdges() []models.Connection {
 		edges := []models.Connection{}
 
 		for result.Next() {
-			record := result.Record()
-			data := record.AsMap()
-			time := int(data["tiempo"].(int64))
-			capacity := int(data["capacidad"].(int64))
-			edge := models.Connection{data["source"].(string), data["target"].(string), time, data["traffic"].(string), capacity, "?"}
-			edges = append(edges, edge)
-
+			edges = append(edges, connectionFromRecord(result.Record().AsMap()))
 		}
 		return edges, nil
 	})
 	if err != nil {
-		return nil
+		return nil, fmt.Errorf("error fetching connections: %w", err)
 	}
-	return t.([]models.Connection)
+	return t.([]models.Connection), nil
 }
 
-// session := r.Driver.NewSession(neo4j.SessionConfig{})
+// session := newSession(ctx, r.Driver)
 // defer session.Close()
+
+// Guarda la intermediación de cada tramo como propiedad de la relación CONECTA
+func (r *RouteRepository) SaveSegmentCentrality(ctx context.Context, scores []models.SegmentCentrality) error {
+	query := `UNWIND $scores AS score
+	MATCH (n {nombre: score.source})-[z:CONECTA]->(y {nombre: score.target})
+	SET z.betweenness = score.betweenness`
+
+	rows := make([]map[string]interface{}, 0, len(scores))
+	for _, score := range scores {
+		rows = append(rows, map[string]interface{}{
+			"source":      score.Source,
+			"target":      score.Target,
+			"betweenness": score.Betweenness,
+		})
+	}
+
+	session := newSession(ctx, r.Driver, "RouteRepository.SaveSegmentCentrality")
+	defer session.Close()
+
+	_, err := session.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		return tx.Run(query, map[string]interface{}{"scores": rows})
+	})
+	if err != nil {
+		return fmt.Errorf("error saving segment centrality: %w", err)
+	}
+	return nil
+}
+
+// Aplica los cambios al tramo y retorna la conexión antes y después de modificarla
+func (r *RouteRepository) UpdateSegment(ctx context.Context, source, target string, update models.SegmentUpdate) (models.Connection, models.Connection, error) {
+	query := `MATCH (n {nombre: $source})-[z:CONECTA]->(y {nombre: $target})
+	WITH n, y, z, z {.*} AS before
+	SET z.trafico_actual = coalesce($trafico, z.trafico_actual),
+	z.accesible = coalesce($accesible, z.accesible),
+	z.tiempo_minutos = coalesce($tiempo, z.tiempo_minutos),
+	z.capacidad = coalesce($capacidad, z.capacidad)
+	RETURN n.nombre AS source,
+	y.nombre AS target,
+	before,
+	z.capacidad AS capacidad,
+	z.trafico_actual AS traffic,
+	z.tiempo_minutos AS tiempo,
+	z.accesible AS accesible`
+
+	params := map[string]interface{}{"source": source, "target": target, "trafico": nil, "accesible": nil, "tiempo": nil, "capacidad": nil}
+	if update.Trafico != nil {
+		params["trafico"] = *update.Trafico
+	}
+	if update.Accesible != nil {
+		params["accesible"] = *update.Accesible
+	}
+	if update.TiempoMinutos != nil {
+		params["tiempo"] = *update.TiempoMinutos
+	}
+	if update.Capacidad != nil {
+		params["capacidad"] = *update.Capacidad
+	}
+
+	session := newSession(ctx, r.Driver, "RouteRepository.UpdateSegment")
+	defer session.Close()
+
+	t, err := session.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		result, err := tx.Run(query, params)
+		if err != nil {
+			return nil, err
+		}
+		if !result.Next() {
+			return nil, fmt.Errorf("%w: segment %s -> %s", ErrNotFound, source, target)
+		}
+		data := result.Record().AsMap()
+		after := connectionFromRecord(data)
+
+		props, _ := data["before"].(map[string]interface{})
+		before := connectionFromRecord(map[string]any{
+			"source":    after.Source,
+			"target":    after.Target,
+			"capacidad": props["capacidad"],
+			"traffic":   props["trafico_actual"],
+			"tiempo":    props["tiempo_minutos"],
+			"accesible": props["accesible"],
+		})
+		return [2]models.Connection{before, after}, nil
+	})
+	if err != nil {
+		return models.Connection{}, models.Connection{}, err
+	}
+	pair := t.([2]models.Connection)
+	return pair[0], pair[1], nil
+}
+
+// Crea o actualiza tramos CONECTA en lotes. tiempo_minutos y accesible siempre quedan definidos.
+// Retorna las propiedades de cada tramo antes y después, también las de los lotes ya aplicados si falla uno
+func (r *RouteRepository) UpsertConnections(ctx context.Context, connections []models.ImportConnection, batchSize int) ([]models.PropertyChange, error) {
+	query := `UNWIND $rows AS row
+	MATCH (n:Zona {nombre: row.source})
+	MATCH (y:Zona {nombre: row.target})
+	OPTIONAL MATCH (n)-[old:CONECTA]->(y)
+	WITH row, n, y, head(collect(old {.*})) AS before
+	MERGE (n)-[z:CONECTA]->(y)
+	SET z.tiempo_minutos = row.tiempo,
+	z.trafico_actual = coalesce(row.trafico, z.trafico_actual, 'bajo'),
+	z.capacidad = coalesce(row.capacidad, z.capacidad, 0),
+	z.accesible = coalesce(row.accesible, z.accesible, true)
+	RETURN row.source AS source, row.target AS target, before, z {.*} AS after`
+
+	rows := make([]map[string]interface{}, 0, len(connections))
+	for _, connection := range connections {
+		row := map[string]interface{}{
+			"source":    connection.Source,
+			"target":    connection.Target,
+			"tiempo":    connection.TiempoMinutos,
+			"trafico":   nil,
+			"capacidad": nil,
+			"accesible": nil,
+		}
+		if connection.Trafico != "" {
+			row["trafico"] = connection.Trafico
+		}
+		if connection.Capacidad > 0 {
+			row["capacidad"] = connection.Capacidad
+		}
+		if connection.Accesible != nil {
+			row["accesible"] = *connection.Accesible
+		}
+		rows = append(rows, row)
+	}
+
+	changes := []models.PropertyChange{}
+	err := writeBatches(ctx, r.Driver, "RouteRepository.UpsertConnections", query, rows, batchSize, func(data map[string]any) {
+		change := models.PropertyChange{}
+		change.Source, _ = data["source"].(string)
+		change.Target, _ = data["target"].(string)
+		if before, ok := data["before"].(map[string]interface{}); ok {
+			change.Before = before
+		}
+		change.After = data["after"]
+		changes = append(changes, change)
+	})
+	if err != nil {
+		return changes, fmt.Errorf("error importing connections: %w", err)
+	}
+	return changes, nil
+}
+
+// Tramos con las propiedades que GetAllAsGraph espera, para la validación de integridad
+func (r *RouteRepository) FindSegmentRecords(ctx context.Context) ([]models.SegmentRecord, error) {
+	query := `MATCH (n)-[z:CONECTA]->(y)
+	RETURN ID(z) AS id,
+	n.nombre AS source,
+	y.nombre AS target,
+	z.tiempo_minutos AS tiempo,
+	z.accesible AS accesible,
+	z.trafico_actual AS traffic,
+	z.capacidad AS capacidad
+	ORDER BY id`
+
+	session := newSession(ctx, r.Driver, "RouteRepository.FindSegmentRecords")
+	defer session.Close()
+
+	t, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		result, err := tx.Run(query, nil)
+		if err != nil {
+			return nil, err
+		}
+		records := []models.SegmentRecord{}
+
+		for result.Next() {
+			data := result.Record().AsMap()
+			record := models.SegmentRecord{Accesible: true}
+			record.ID, _ = data["id"].(int64)
+			if val, ok := data["source"].(string); ok {
+				record.Source = val
+			} else {
+				record.Missing = append(record.Missing, "source.nombre")
+			}
+			if val, ok := data["target"].(string); ok {
+				record.Target = val
+			} else {
+				record.Missing = append(record.Missing, "target.nombre")
+			}
+			// GetAllAsGraph exige un entero; un decimal se reporta como faltante pero se conserva su valor
+			switch val := data["tiempo"].(type) {
+			case int64:
+				record.TiempoMinutos = float64(val)
+			case float64:
+				record.TiempoMinutos = val
+				record.Missing = append(record.Missing, "tiempo_minutos")
+			default:
+				record.Missing = append(record.Missing, "tiempo_minutos")
+			}
+			if val, ok := data["accesible"].(bool); ok {
+				record.Accesible = val
+			} else {
+				record.Missing = append(record.Missing, "accesible")
+			}
+			if _, ok := data["traffic"].(string); !ok {
+				record.Missing = append(record.Missing, "trafico_actual")
+			}
+			if _, ok := data["capacidad"].(int64); !ok {
+				record.Missing = append(record.Missing, "capacidad")
+			}
+			records = append(records, record)
+		}
+		return records, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching segment records: %w", err)
+	}
+	return t.([]models.SegmentRecord), nil
+}
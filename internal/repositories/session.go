@@ -0,0 +1,22 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Driver que puede asociar la sesión a un contexto, como el que crea el paquete database
+type contextDriver interface {
+	NewSessionContext(ctx context.Context, method string, config neo4j.SessionConfig) neo4j.Session
+}
+
+// Abre una sesión ligada a ctx si el driver lo permite, así las consultas quedan dentro
+// de la traza y el log de la petición que las origina. method, como "ZoneRepository.GetAllAsGraph",
+// nombra el span y la serie de las métricas
+func newSession(ctx context.Context, driver neo4j.Driver, method string) neo4j.Session {
+	if traced, ok := driver.(contextDriver); ok {
+		return traced.NewSessionContext(ctx, method, neo4j.SessionConfig{})
+	}
+	return driver.NewSession(neo4j.SessionConfig{})
+}
@@ -0,0 +1,368 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"neo4j_delivery/internal/models"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+type SnapshotRepository struct {
+	Driver neo4j.Driver
+}
+
+func NewSnapshotRepository(driver neo4j.Driver) *SnapshotRepository {
+	return &SnapshotRepository{Driver: driver}
+}
+
+const snapshotInfoReturn = `RETURN s.version AS version,
+	s.created_at AS created_at,
+	s.actor AS actor,
+	s.descripcion AS descripcion,
+	s.zone_count AS zone_count,
+	s.segment_count AS segment_count`
+
+func snapshotInfoFromRecord(data map[string]any) models.SnapshotInfo {
+	info := models.SnapshotInfo{}
+	if val, ok := data["version"].(int64); ok {
+		info.Version = int(val)
+	}
+	info.CreatedAt, _ = data["created_at"].(time.Time)
+	info.Actor, _ = data["actor"].(string)
+	info.Description, _ = data["descripcion"].(string)
+	if val, ok := data["zone_count"].(int64); ok {
+		info.ZoneCount = int(val)
+	}
+	if val, ok := data["segment_count"].(int64); ok {
+		info.SegmentCount = int(val)
+	}
+	return info
+}
+
+// Decodifica JSON conservando la distinción entre enteros y decimales, que GetAllAsGraph necesita
+func decodeProps(data string, target interface{}) error {
+	decoder := json.NewDecoder(strings.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(target)
+}
+
+func restoreNumbers(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case json.Number:
+		if integer, err := typed.Int64(); err == nil {
+			return integer
+		}
+		float, _ := typed.Float64()
+		return float
+	case []interface{}:
+		for i := range typed {
+			typed[i] = restoreNumbers(typed[i])
+		}
+		return typed
+	case map[string]interface{}:
+		for key := range typed {
+			typed[key] = restoreNumbers(typed[key])
+		}
+		return typed
+	}
+	return value
+}
+
+// Escribe los decimales siempre con punto para no confundirlos con enteros al restaurar
+func markFloats(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case float64:
+		text := strconv.FormatFloat(typed, 'f', -1, 64)
+		if !strings.ContainsAny(text, ".eE") {
+			text += ".0"
+		}
+		return json.Number(text)
+	case []interface{}:
+		marked := make([]interface{}, len(typed))
+		for i := range typed {
+			marked[i] = markFloats(typed[i])
+		}
+		return marked
+	case map[string]interface{}:
+		marked := make(map[string]interface{}, len(typed))
+		for key := range typed {
+			marked[key] = markFloats(typed[key])
+		}
+		return marked
+	}
+	return value
+}
+
+func encodeProps(value interface{}) (string, error) {
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// Guarda zonas y tramos actuales como un nuevo snapshot con la versión siguiente
+func (r *SnapshotRepository) Capture(ctx context.Context, actor, description string) (models.SnapshotInfo, error) {
+	zonesQuery := `MATCH (z:Zona)
+	WHERE z.nombre IS NOT NULL
+	RETURN z.nombre AS nombre,
+	'CentroDistribucion' IN labels(z) AS centro,
+	properties(z) AS props
+	ORDER BY nombre`
+	segmentsQuery := `MATCH (n:Zona)-[r:CONECTA]->(m:Zona)
+	WHERE n.nombre IS NOT NULL AND m.nombre IS NOT NULL
+	RETURN n.nombre AS source,
+	m.nombre AS target,
+	properties(r) AS props
+	ORDER BY source, target`
+	createQuery := `OPTIONAL MATCH (previous:Snapshot)
+	WITH coalesce(max(previous.version), 0) + 1 AS version
+	CREATE (s:Snapshot {
+		version: version,
+		created_at: $created_at,
+		actor: $actor,
+		descripcion: $descripcion,
+		zonas: $zonas,
+		tramos: $tramos,
+		zone_count: $zone_count,
+		segment_count: $segment_count
+	})
+	` + snapshotInfoReturn
+
+	session := newSession(ctx, r.Driver, "SnapshotRepository.Capture")
+	defer session.Close()
+
+	capture := func(tx neo4j.Transaction) (interface{}, error) {
+		result, err := tx.Run(zonesQuery, nil)
+		if err != nil {
+			return nil, err
+		}
+		zones := []models.SnapshotZone{}
+		for result.Next() {
+			data := result.Record().AsMap()
+			zone := models.SnapshotZone{}
+			zone.Nombre, _ = data["nombre"].(string)
+			zone.Centro, _ = data["centro"].(bool)
+			if props, ok := data["props"].(map[string]interface{}); ok {
+				zone.Props = markFloats(props).(map[string]interface{})
+			}
+			zones = append(zones, zone)
+		}
+
+		result, err = tx.Run(segmentsQuery, nil)
+		if err != nil {
+			return nil, err
+		}
+		segments := []models.SnapshotSegment{}
+		for result.Next() {
+			data := result.Record().AsMap()
+			segment := models.SnapshotSegment{}
+			segment.Source, _ = data["source"].(string)
+			segment.Target, _ = data["target"].(string)
+			if props, ok := data["props"].(map[string]interface{}); ok {
+				segment.Props = markFloats(props).(map[string]interface{})
+			}
+			segments = append(segments, segment)
+		}
+
+		zonesJSON, err := encodeProps(zones)
+		if err != nil {
+			return nil, err
+		}
+		segmentsJSON, err := encodeProps(segments)
+		if err != nil {
+			return nil, err
+		}
+		result, err = tx.Run(createQuery, map[string]interface{}{
+			"created_at":    time.Now().UTC(),
+			"actor":         actor,
+			"descripcion":   description,
+			"zonas":         zonesJSON,
+			"tramos":        segmentsJSON,
+			"zone_count":    len(zones),
+			"segment_count": len(segments),
+		})
+		if err != nil {
+			return nil, err
+		}
+		record, err := result.Single()
+		if err != nil {
+			return nil, err
+		}
+		return snapshotInfoFromRecord(record.AsMap()), nil
+	}
+
+	// Dos capturas simultáneas calculan la misma versión; la restricción snapshot_version de
+	// scripts/schema.cypher rechaza la segunda, que se repite con la versión siguiente
+	var t interface{}
+	var err error
+	for attempt := 1; attempt <= captureAttempts; attempt++ {
+		t, err = session.WriteTransaction(capture)
+		if !isConstraintConflict(err) {
+			break
+		}
+	}
+	if err != nil {
+		return models.SnapshotInfo{}, fmt.Errorf("error capturing snapshot: %w", err)
+	}
+	return t.(models.SnapshotInfo), nil
+}
+
+const captureAttempts = 3
+
+func isConstraintConflict(err error) bool {
+	var neo4jErr *neo4j.Neo4jError
+	return errors.As(err, &neo4jErr) && neo4jErr.Code == "Neo.ClientError.Schema.ConstraintValidationFailed"
+}
+
+// Snapshots sin su contenido, el más reciente primero
+func (r *SnapshotRepository) List(ctx context.Context) ([]models.SnapshotInfo, error) {
+	query := `MATCH (s:Snapshot)
+	` + snapshotInfoReturn + `
+	ORDER BY version DESC`
+
+	session := newSession(ctx, r.Driver, "SnapshotRepository.List")
+	defer session.Close()
+
+	t, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		result, err := tx.Run(query, nil)
+		if err != nil {
+			return nil, err
+		}
+		snapshots := []models.SnapshotInfo{}
+		for result.Next() {
+			snapshots = append(snapshots, snapshotInfoFromRecord(result.Record().AsMap()))
+		}
+		return snapshots, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching snapshots: %w", err)
+	}
+	return t.([]models.SnapshotInfo), nil
+}
+
+// Snapshot con la versión dada
+func (r *SnapshotRepository) Find(ctx context.Context, version int) (models.Snapshot, error) {
+	return r.findOne(ctx, "SnapshotRepository.Find", `MATCH (s:Snapshot {version: $version})`, map[string]interface{}{"version": version}, fmt.Sprintf("version %d", version))
+}
+
+// Último snapshot tomado en o antes de at
+func (r *SnapshotRepository) FindAt(ctx context.Context, at time.Time) (models.Snapshot, error) {
+	return r.findOne(ctx, "SnapshotRepository.FindAt", `MATCH (s:Snapshot)
+	WHERE s.created_at <= $at
+	WITH s ORDER BY s.created_at DESC LIMIT 1`, map[string]interface{}{"at": at.UTC()}, "at "+at.Format(time.RFC3339))
+}
+
+func (r *SnapshotRepository) findOne(ctx context.Context, method, match string, params map[string]interface{}, description string) (models.Snapshot, error) {
+	query := match + `
+	` + snapshotInfoReturn + `,
+	s.zonas AS zonas,
+	s.tramos AS tramos`
+
+	session := newSession(ctx, r.Driver, method)
+	defer session.Close()
+
+	t, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		result, err := tx.Run(query, params)
+		if err != nil {
+			return nil, err
+		}
+		if !result.Next() {
+			return nil, fmt.Errorf("%w: snapshot %s", ErrNotFound, description)
+		}
+		data := result.Record().AsMap()
+		snapshot := models.Snapshot{SnapshotInfo: snapshotInfoFromRecord(data)}
+
+		zonesJSON, _ := data["zonas"].(string)
+		if err := decodeProps(zonesJSON, &snapshot.Zones); err != nil {
+			return nil, fmt.Errorf("corrupt zones in snapshot %d: %w", snapshot.Version, err)
+		}
+		for i := range snapshot.Zones {
+			restoreNumbers(snapshot.Zones[i].Props)
+		}
+		segmentsJSON, _ := data["tramos"].(string)
+		if err := decodeProps(segmentsJSON, &snapshot.Segments); err != nil {
+			return nil, fmt.Errorf("corrupt segments in snapshot %d: %w", snapshot.Version, err)
+		}
+		for i := range snapshot.Segments {
+			restoreNumbers(snapshot.Segments[i].Props)
+		}
+		return snapshot, nil
+	})
+	if err != nil {
+		return models.Snapshot{}, err
+	}
+	return t.(models.Snapshot), nil
+}
+
+// Reemplaza la red actual por la del snapshot en una sola transacción. Las zonas que no están
+// en el snapshot se eliminan junto con sus relaciones, incluidas las de la flota
+func (r *SnapshotRepository) Restore(ctx context.Context, snapshot models.Snapshot) error {
+	names := make([]string, 0, len(snapshot.Zones))
+	zones := make([]map[string]interface{}, 0, len(snapshot.Zones))
+	for _, zone := range snapshot.Zones {
+		names = append(names, zone.Nombre)
+		props := make(map[string]interface{}, len(zone.Props)+1)
+		for key, value := range zone.Props {
+			props[key] = value
+		}
+		props["nombre"] = zone.Nombre
+		zones = append(zones, map[string]interface{}{"nombre": zone.Nombre, "centro": zone.Centro, "props": props})
+	}
+	segments := make([]map[string]interface{}, 0, len(snapshot.Segments))
+	for _, segment := range snapshot.Segments {
+		props := segment.Props
+		if props == nil {
+			props = map[string]interface{}{}
+		}
+		segments = append(segments, map[string]interface{}{"source": segment.Source, "target": segment.Target, "props": props})
+	}
+
+	statements := []struct {
+		query  string
+		params map[string]interface{}
+	}{
+		{`MATCH (:Zona)-[r:CONECTA]->(:Zona) DELETE r`, nil},
+		{`MATCH (z:Zona) WHERE z.nombre IS NULL OR NOT z.nombre IN $names DETACH DELETE z`, map[string]interface{}{"names": names}},
+		{`UNWIND $rows AS row
+		MERGE (z:Zona {nombre: row.nombre})
+		SET z = row.props
+		FOREACH (_ IN CASE WHEN row.centro THEN [1] ELSE [] END | SET z:CentroDistribucion)
+		FOREACH (_ IN CASE WHEN row.centro THEN [] ELSE [1] END | REMOVE z:CentroDistribucion)`, map[string]interface{}{"rows": zones}},
+		{`UNWIND $rows AS row
+		MATCH (n:Zona {nombre: row.source})
+		MATCH (m:Zona {nombre: row.target})
+		CREATE (n)-[r:CONECTA]->(m)
+		SET r = row.props`, map[string]interface{}{"rows": segments}},
+	}
+
+	session := newSession(ctx, r.Driver, "SnapshotRepository.Restore")
+	defer session.Close()
+
+	_, err := session.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		for _, statement := range statements {
+			result, err := tx.Run(statement.query, statement.params)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := result.Consume(); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	if err != nil {
+		return fmt.Errorf("error restoring snapshot %d: %w", snapshot.Version, err)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"neo4j_delivery/internal/models"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+const trackingReturn = `RETURN v.id AS id,
+	v.placa AS placa,
+	v.destino AS destino,
+	v.ruta_planificada AS ruta_planificada,
+	v.ruta_restante AS ruta_restante,
+	v.zona_actual AS zona,
+	v.tramo_origen AS source,
+	v.tramo_destino AS target,
+	v.progreso AS progreso,
+	v.salida AS salida,
+	v.eta_planificada AS eta_planificada,
+	v.eta_actual AS eta_actual,
+	v.retraso_minutos AS retraso,
+	v.desviado AS desviado,
+	v.reportado_en AS reportado_en`
+
+func stringList(value any) []string {
+	items, _ := value.([]interface{})
+	list := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+func trackingFromRecord(data map[string]any) models.VehicleTracking {
+	tracking := models.VehicleTracking{
+		RutaPlanificada: stringList(data["ruta_planificada"]),
+		RutaRestante:    stringList(data["ruta_restante"]),
+	}
+	tracking.VehiculoID, _ = data["id"].(string)
+	tracking.Placa, _ = data["placa"].(string)
+	tracking.Destino, _ = data["destino"].(string)
+	tracking.Zona, _ = data["zona"].(string)
+	tracking.Source, _ = data["source"].(string)
+	tracking.Target, _ = data["target"].(string)
+	tracking.Progreso, _ = data["progreso"].(float64)
+	tracking.Salida, _ = data["salida"].(time.Time)
+	tracking.EtaPlanificada, _ = data["eta_planificada"].(time.Time)
+	tracking.EtaActual, _ = data["eta_actual"].(time.Time)
+	tracking.RetrasoMinutos, _ = data["retraso"].(float64)
+	tracking.Desviado, _ = data["desviado"].(bool)
+	tracking.ReportadoEn, _ = data["reportado_en"].(time.Time)
+	return tracking
+}
+
+// Guarda el seguimiento del vehículo. Si estado no es vacío también actualiza el estado del vehículo
+func (r *FleetRepository) SaveTracking(ctx context.Context, tracking models.VehicleTracking, estado string) error {
+	query := `MATCH (v:Vehiculo {id: $id})
+	SET v.destino = $destino,
+	v.ruta_planificada = $ruta_planificada,
+	v.ruta_restante = $ruta_restante,
+	v.zona_actual = $zona,
+	v.tramo_origen = $source,
+	v.tramo_destino = $target,
+	v.progreso = $progreso,
+	v.salida = $salida,
+	v.eta_planificada = $eta_planificada,
+	v.eta_actual = $eta_actual,
+	v.retraso_minutos = $retraso,
+	v.desviado = $desviado,
+	v.reportado_en = $reportado_en,
+	v.estado = CASE WHEN $estado = '' THEN v.estado ELSE $estado END
+	RETURN count(v) AS updated`
+
+	params := map[string]interface{}{
+		"id":               tracking.VehiculoID,
+		"destino":          tracking.Destino,
+		"ruta_planificada": tracking.RutaPlanificada,
+		"ruta_restante":    tracking.RutaRestante,
+		"zona":             tracking.Zona,
+		"source":           tracking.Source,
+		"target":           tracking.Target,
+		"progreso":         tracking.Progreso,
+		"salida":           tracking.Salida,
+		"eta_planificada":  tracking.EtaPlanificada,
+		"eta_actual":       tracking.EtaActual,
+		"retraso":          tracking.RetrasoMinutos,
+		"desviado":         tracking.Desviado,
+		"reportado_en":     tracking.ReportadoEn,
+		"estado":           estado,
+	}
+
+	session := newSession(ctx, r.Driver, "FleetRepository.SaveTracking")
+	defer session.Close()
+
+	_, err := session.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		result, err := tx.Run(query, params)
+		if err != nil {
+			return nil, err
+		}
+		record, err := result.Single()
+		if err != nil {
+			return nil, err
+		}
+		if updated, _ := record.AsMap()["updated"].(int64); updated == 0 {
+			return nil, fmt.Errorf("%w: vehicle %q", ErrNotFound, tracking.VehiculoID)
+		}
+		return nil, nil
+	})
+	return err
+}
+
+func (r *FleetRepository) FindTracking(ctx context.Context, id string) (models.VehicleTracking, error) {
+	query := `MATCH (v:Vehiculo {id: $id})
+	WHERE v.ruta_planificada IS NOT NULL
+	` + trackingReturn
+
+	session := newSession(ctx, r.Driver, "FleetRepository.FindTracking")
+	defer session.Close()
+
+	t, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		result, err := tx.Run(query, map[string]interface{}{"id": id})
+		if err != nil {
+			return nil, err
+		}
+		if !result.Next() {
+			return nil, fmt.Errorf("%w: no dispatched route for vehicle %q", ErrNotFound, id)
+		}
+		return trackingFromRecord(result.Record().AsMap()), nil
+	})
+	if err != nil {
+		return models.VehicleTracking{}, err
+	}
+	return t.(models.VehicleTracking), nil
+}
+
+// Vehículos en ruta con su último seguimiento guardado. El retraso lo recalcula el servicio con el
+// grafo y la hora actuales, porque el guardado solo cambia cuando llega un reporte de posición
+func (r *FleetRepository) FindVehiclesInRoute(ctx context.Context) ([]models.VehicleTracking, error) {
+	query := `MATCH (v:Vehiculo {estado: $en_ruta})
+	WHERE v.ruta_planificada IS NOT NULL
+	` + trackingReturn
+
+	session := newSession(ctx, r.Driver, "FleetRepository.FindVehiclesInRoute")
+	defer session.Close()
+
+	t, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		result, err := tx.Run(query, map[string]interface{}{"en_ruta": models.VehiculoEnRuta})
+		if err != nil {
+			return nil, err
+		}
+		vehicles := []models.VehicleTracking{}
+		for result.Next() {
+			vehicles = append(vehicles, trackingFromRecord(result.Record().AsMap()))
+		}
+		return vehicles, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching vehicles in route: %w", err)
+	}
+	return t.([]models.VehicleTracking), nil
+}
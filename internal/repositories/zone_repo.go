@@ -16,137 +16,186 @@ func NewZoneRepository(driver neo4j.Driver) *ZoneRepository {
 	return &ZoneRepository{Driver: driver}
 }
 
-func (r *ZoneRepository) GetGraphData() (models.GraphData, error) {
-	session := r.Driver.NewSession(neo4j.SessionConfig{})
+func (r *ZoneRepository) GetGraphData(ctx context.Context) (models.GraphData, error) {
+	return r.QueryGraph(ctx, models.GraphQuery{})
+}
+
+// Convierte un nodo de Neo4j al formato del frontend
+func graphNode(node neo4j.Node) models.Node {
+	// Obtener propiedades con comprobación de nil
+	nombre := ""
+	if val, ok := node.Props["nombre"].(string); ok {
+		nombre = val
+	}
+
+	tipoZona := ""
+	if val, ok := node.Props["tipo_zona"].(string); ok {
+		tipoZona = val
+	}
+
+	return models.Node{
+		ID:       strconv.FormatInt(node.Id, 10),
+		Name:     nombre,
+		Label:    getNodeLabel(node),
+		Tipo:     tipoZona,
+		Lat:      floatProp(node.Props["latitud"]),
+		Lng:      floatProp(node.Props["longitud"]),
+		Boundary: boundaryProp(node.Props["limite"]),
+	}
+}
+
+// Convierte una relación CONECTA al formato del frontend, con valores por defecto
+func graphLink(rel neo4j.Relationship) models.Link {
+	tiempo := 0.0
+	if val, ok := rel.Props["tiempo_minutos"].(int64); ok {
+		tiempo = float64(val)
+	} else if val, ok := rel.Props["tiempo_minutos"].(float64); ok {
+		tiempo = val
+	}
+
+	trafico := ""
+	if val, ok := rel.Props["trafico_actual"].(string); ok {
+		trafico = val
+	}
+
+	capacidad := 0
+	if val, ok := rel.Props["capacidad"].(int64); ok {
+		capacidad = int(val)
+	}
+
+	accesible := true
+	if val, ok := rel.Props["accesible"].(bool); ok {
+		accesible = val
+	}
+
+	return models.Link{
+		Source:         strconv.FormatInt(rel.StartId, 10),
+		Target:         strconv.FormatInt(rel.EndId, 10),
+		Tiempo_minutos: tiempo,
+		Trafico_actual: trafico,
+		Capacidad:      capacidad,
+		Accesible:      accesible,
+	}
+}
+
+// Retorna las zonas que cumplen los filtros, ordenadas por nombre y paginadas, junto con los
+// tramos entre ellas que cumplen los filtros de tráfico y accesibilidad
+func (r *ZoneRepository) QueryGraph(ctx context.Context, query models.GraphQuery) (models.GraphData, error) {
+	nodeFilter := `MATCH (n:Zona)
+	WHERE ($names IS NULL OR n.nombre IN $names)
+	AND ($tipos IS NULL OR n.tipo_zona IN $tipos)
+	AND ($label IS NULL OR $label IN labels(n))`
+	countQuery := nodeFilter + `
+	RETURN count(n) AS total`
+	nodesQuery := nodeFilter + `
+	RETURN n
+	ORDER BY n.nombre, ID(n)
+	SKIP $offset`
+	if query.Limit > 0 {
+		nodesQuery += `
+	LIMIT $limit`
+	}
+	linksQuery := `MATCH (n)-[r:CONECTA]->(m)
+	WHERE ID(n) IN $ids AND ID(m) IN $ids
+	AND ($traficos IS NULL OR r.trafico_actual IN $traficos)
+	AND ($accesible IS NULL OR coalesce(r.accesible, true) = $accesible)
+	RETURN r
+	ORDER BY ID(r)`
+
+	params := map[string]interface{}{
+		"names":     nil,
+		"tipos":     nil,
+		"label":     nil,
+		"traficos":  nil,
+		"accesible": nil,
+		"offset":    query.Offset,
+		"limit":     query.Limit,
+	}
+	if query.Names != nil {
+		params["names"] = query.Names
+	}
+	if len(query.TipoZona) > 0 {
+		params["tipos"] = query.TipoZona
+	}
+	if query.Label != "" {
+		params["label"] = query.Label
+	}
+	if len(query.Trafico) > 0 {
+		params["traficos"] = query.Trafico
+	}
+	if query.Accesible != nil {
+		params["accesible"] = *query.Accesible
+	}
+
+	session := newSession(ctx, r.Driver, "ZoneRepository.QueryGraph")
 	defer session.Close()
 
 	result, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		query := `
-		MATCH (n)
-		OPTIONAL MATCH (n)-[r:CONECTA]->(m)
-		RETURN n, r, m
-		`
-		result, err := tx.Run(query, nil)
+		result, err := tx.Run(countQuery, params)
 		if err != nil {
 			return nil, err
 		}
+		record, err := result.Single()
+		if err != nil {
+			return nil, err
+		}
+		total, _ := record.Values[0].(int64)
 
-		nodes := make(map[string]models.Node)
-		links := []models.Link{}
-
+		result, err = tx.Run(nodesQuery, params)
+		if err != nil {
+			return nil, err
+		}
+		nodes := []models.Node{}
+		ids := []int64{}
 		for result.Next() {
-			record := result.Record()
-			
-			// Procesar nodo origen
-			if nodeVal, ok := record.Get("n"); ok && nodeVal != nil {
-				if node, ok := nodeVal.(neo4j.Node); ok {
-					nodeId := strconv.FormatInt(node.Id, 10)
-					
-					// Obtener propiedades con comprobación de nil
-					nombre := ""
-					if val, ok := node.Props["nombre"].(string); ok {
-						nombre = val
-					}
-					
-					tipoZona := ""
-					if val, ok := node.Props["tipo_zona"].(string); ok {
-						tipoZona = val
-					}
-
-					// Determinar si es centro de distribución
-					isCentro := false
-					for _, label := range node.Labels {
-						if label == "CentroDistribucion" {
-							isCentro = true
-							break
-						}
-					}
-
-					label := "Zona"
-					if isCentro {
-						label = "CentroDistribucion"
-					}
-
-					nodes[nodeId] = models.Node{
-						ID:    nodeId,
-						Name:  nombre,
-						Label: label,
-						Tipo:  tipoZona,
-					}
-				}
-			}
-			
-			// Procesar relación si existe
-			if relVal, ok := record.Get("r"); ok && relVal != nil {
-				if rel, ok := relVal.(neo4j.Relationship); ok {
-					if targetVal, ok := record.Get("m"); ok && targetVal != nil {
-						if targetNode, ok := targetVal.(neo4j.Node); ok {
-							targetId := strconv.FormatInt(targetNode.Id, 10)
-							
-							// Obtener propiedades con valores por defecto
-                tiempo := 0.0
-                if val, ok := rel.Props["tiempo_minutos"].(int64); ok {
-                    tiempo = float64(val)
-                } else if val, ok := rel.Props["tiempo_minutos"].(float64); ok {
-                    tiempo = val
-                }
-
-							trafico := ""
-							if val, ok := rel.Props["trafico_actual"].(string); ok {
-								trafico = val
-							}
-
-							capacidad := 0
-							if val, ok := rel.Props["capacidad"].(int64); ok {
-								capacidad = int(val)
-							}
-
-							accesible := true
-							if val, ok := rel.Props["accesible"].(bool); ok {
-								accesible = val
-							}
-
-							links = append(links, models.Link{
-								Source:         strconv.FormatInt(rel.StartId, 10),
-								Target:         targetId,
-								Tiempo_minutos: tiempo,
-								Trafico_actual: trafico,
-								Capacidad:      capacidad,
-								Accesible:      accesible,
-							})
-						}
-					}
-				}
+			if node, ok := result.Record().Values[0].(neo4j.Node); ok {
+				nodes = append(nodes, graphNode(node))
+				ids = append(ids, node.Id)
 			}
 		}
 
-		// Convertir el mapa de nodos a slice
-		nodeSlice := make([]models.Node, 0, len(nodes))
-		for _, node := range nodes {
-			nodeSlice = append(nodeSlice, node)
+		params["ids"] = ids
+		result, err = tx.Run(linksQuery, params)
+		if err != nil {
+			return nil, err
+		}
+		links := []models.Link{}
+		for result.Next() {
+			if rel, ok := result.Record().Values[0].(neo4j.Relationship); ok {
+				links = append(links, graphLink(rel))
+			}
 		}
 
 		return models.GraphData{
-			Nodes: nodeSlice,
+			Nodes: nodes,
 			Links: links,
+			Page: &models.GraphPage{
+				Offset:     query.Offset,
+				Limit:      query.Limit,
+				TotalNodes: int(total),
+				HasMore:    query.Offset+len(nodes) < int(total),
+			},
 		}, nil
 	})
 
 	if err != nil {
-		return models.GraphData{}, err
+		return models.GraphData{}, fmt.Errorf("error fetching graph: %w", err)
 	}
 
 	return result.(models.GraphData), nil
 }
 
 func (r *ZoneRepository) FindAll(ctx context.Context) ([]models.Zone, error) {
-	session := r.Driver.NewSession(neo4j.SessionConfig{})
+	session := newSession(ctx, r.Driver, "ZoneRepository.FindAll")
 	defer session.Close()
 
 	result, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
 		query := `
 		MATCH (z:Zona)
-		RETURN z.nombre AS nombre, z.tipo_zona AS tipo
+		RETURN z.nombre AS nombre, z.tipo_zona AS tipo, z.poblacion AS poblacion,
+			z.ventana_inicio AS ventana_inicio, z.ventana_fin AS ventana_fin,
+			z.tiempo_servicio AS tiempo_servicio,
+			z.latitud AS latitud, z.longitud AS longitud, z.limite AS limite
 		ORDER BY z.nombre
 		`
 		result, err := tx.Run(query, nil)
@@ -157,10 +206,29 @@ func (r *ZoneRepository) FindAll(ctx context.Context) ([]models.Zone, error) {
 		var zones []models.Zone
 		for result.Next() {
 			record := result.Record()
-			zones = append(zones, models.Zone{
-				Nombre:   record.Values[0].(string),
-				TipoZona: record.Values[1].(string),
-			})
+			// Una zona sin nombre o tipo_zona no debe tumbar el listado; ValidateNetwork la reporta
+			zone := models.Zone{}
+			zone.Nombre, _ = record.Values[0].(string)
+			zone.TipoZona, _ = record.Values[1].(string)
+			if val, ok := record.Values[2].(int64); ok {
+				poblacion := int(val)
+				zone.Poblacion = &poblacion
+			}
+			if val, ok := record.Values[3].(string); ok {
+				zone.VentanaInicio = val
+			}
+			if val, ok := record.Values[4].(string); ok {
+				zone.VentanaFin = val
+			}
+			if val, ok := record.Values[5].(int64); ok {
+				zone.TiempoServicio = float64(val)
+			} else if val, ok := record.Values[5].(float64); ok {
+				zone.TiempoServicio = val
+			}
+			zone.Latitud = floatProp(record.Values[6])
+			zone.Longitud = floatProp(record.Values[7])
+			zone.Limite = boundaryProp(record.Values[8])
+			zones = append(zones, zone)
 		}
 		return zones, nil
 	})
@@ -173,7 +241,7 @@ func (r *ZoneRepository) FindAll(ctx context.Context) ([]models.Zone, error) {
 }
 
 func (r *ZoneRepository) FindOptimalRoute(ctx context.Context, from, to string) ([]models.Connection, error) {
-	session := r.Driver.NewSession(neo4j.SessionConfig{})
+	session := newSession(ctx, r.Driver, "ZoneRepository.FindOptimalRoute")
 	defer session.Close()
 
 	result, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
@@ -218,6 +286,35 @@ func (r *ZoneRepository) FindOptimalRoute(ctx context.Context, from, to string)
 	return result.([]models.Connection), nil
 }
 
+// Propiedad numérica opcional; Neo4j puede devolverla como entero o flotante
+func floatProp(value any) *float64 {
+	switch v := value.(type) {
+	case float64:
+		return &v
+	case int64:
+		f := float64(v)
+		return &f
+	}
+	return nil
+}
+
+// El polígono se guarda como lista plana [lng1, lat1, lng2, lat2, ...] porque Neo4j no admite listas anidadas
+func boundaryProp(value any) [][2]float64 {
+	items, ok := value.([]interface{})
+	if !ok || len(items) < 6 || len(items)%2 != 0 {
+		return nil
+	}
+	boundary := make([][2]float64, 0, len(items)/2)
+	for i := 0; i < len(items); i += 2 {
+		lng, lat := floatProp(items[i]), floatProp(items[i+1])
+		if lng == nil || lat == nil {
+			return nil
+		}
+		boundary = append(boundary, [2]float64{*lng, *lat})
+	}
+	return boundary
+}
+
 func getNodeLabel(node neo4j.Node) string {
     labels := node.Labels
     for _, label := range labels {
@@ -229,16 +326,16 @@ func getNodeLabel(node neo4j.Node) string {
 }
 
 
-func (r *ZoneRepository) GetAllAsGraph() (models.Graph, error) {
+func (r *ZoneRepository) GetAllAsGraph(ctx context.Context) (models.Graph, error) {
 
-	query := `MATCH (n) 
+	query := `MATCH (n:Zona)
 	OPTIONAL MATCH (n)-[z:CONECTA]->(neighbor)
 	RETURN n.nombre AS padre,
 	z.tiempo_minutos AS tiempo, 
 	z.accesible AS accesible,
 	neighbor.nombre AS hijo`
 
-	session := r.Driver.NewSession(neo4j.SessionConfig{})
+	session := newSession(ctx, r.Driver, "ZoneRepository.GetAllAsGraph")
 	defer session.Close()
 
 	t, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
@@ -279,3 +376,204 @@ func hasNode(m *models.Graph, target string) bool {
 	}
 	return false
 }
+
+func (r *ZoneRepository) GetDistributionCenters(ctx context.Context) ([]models.DistributionCenter, error) {
+	query := `MATCH (c:CentroDistribucion)
+	RETURN c.nombre AS nombre,
+	c.tipo_zona AS tipo,
+	c.capacidad_vehiculos AS capacidad
+	ORDER BY c.nombre`
+
+	session := newSession(ctx, r.Driver, "ZoneRepository.GetDistributionCenters")
+	defer session.Close()
+
+	t, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		result, err := tx.Run(query, nil)
+		if err != nil {
+			return nil, err
+		}
+		centers := []models.DistributionCenter{}
+
+		for result.Next() {
+			data := result.Record().AsMap()
+			center := models.DistributionCenter{}
+			if val, ok := data["nombre"].(string); ok {
+				center.Nombre = val
+			}
+			if val, ok := data["tipo"].(string); ok {
+				center.TipoZona = val
+			}
+			if val, ok := data["capacidad"].(int64); ok {
+				center.CapacidadVehiculos = int(val)
+			}
+			centers = append(centers, center)
+		}
+		return centers, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching distribution centers: %w", err)
+	}
+
+	return t.([]models.DistributionCenter), nil
+}
+
+// Guarda las métricas de centralidad como propiedades de cada zona
+func (r *ZoneRepository) SaveZoneCentrality(ctx context.Context, scores []models.ZoneCentrality) error {
+	query := `UNWIND $scores AS score
+	MATCH (z:Zona {nombre: score.zone})
+	SET z.betweenness = score.betweenness,
+	z.closeness = score.closeness`
+
+	rows := make([]map[string]interface{}, 0, len(scores))
+	for _, score := range scores {
+		rows = append(rows, map[string]interface{}{
+			"zone":        score.Zone,
+			"betweenness": score.Betweenness,
+			"closeness":   score.Closeness,
+		})
+	}
+
+	session := newSession(ctx, r.Driver, "ZoneRepository.SaveZoneCentrality")
+	defer session.Close()
+
+	_, err := session.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		return tx.Run(query, map[string]interface{}{"scores": rows})
+	})
+	if err != nil {
+		return fmt.Errorf("error saving zone centrality: %w", err)
+	}
+	return nil
+}
+
+// Crea o actualiza zonas por nombre en lotes. Las propiedades vacías conservan el valor actual.
+// Retorna las propiedades de cada zona antes y después, también las de los lotes ya aplicados si falla uno
+func (r *ZoneRepository) UpsertZones(ctx context.Context, zones []models.ImportZone, batchSize int) ([]models.PropertyChange, error) {
+	query := `UNWIND $rows AS row
+	OPTIONAL MATCH (old:Zona {nombre: row.nombre})
+	WITH row, head(collect(old {.*})) AS before
+	MERGE (z:Zona {nombre: row.nombre})
+	SET z.tipo_zona = coalesce(row.tipo_zona, z.tipo_zona),
+	z.capacidad_vehiculos = coalesce(row.capacidad_vehiculos, z.capacidad_vehiculos),
+	z.poblacion = coalesce(row.poblacion, z.poblacion),
+	z.latitud = coalesce(row.latitud, z.latitud),
+	z.longitud = coalesce(row.longitud, z.longitud)
+	FOREACH (_ IN CASE WHEN row.centro THEN [1] ELSE [] END | SET z:CentroDistribucion)
+	RETURN row.nombre AS zona, before, z {.*} AS after`
+
+	rows := make([]map[string]interface{}, 0, len(zones))
+	for _, zone := range zones {
+		row := map[string]interface{}{
+			"nombre":              zone.Nombre,
+			"tipo_zona":           nil,
+			"centro":              zone.CentroDistribucion,
+			"capacidad_vehiculos": nil,
+			"poblacion":           nil,
+			"latitud":             nil,
+			"longitud":            nil,
+		}
+		if zone.TipoZona != "" {
+			row["tipo_zona"] = zone.TipoZona
+		}
+		if zone.CapacidadVehiculos > 0 {
+			row["capacidad_vehiculos"] = zone.CapacidadVehiculos
+		}
+		if zone.Poblacion != nil {
+			row["poblacion"] = *zone.Poblacion
+		}
+		if zone.Latitud != nil && zone.Longitud != nil {
+			row["latitud"] = *zone.Latitud
+			row["longitud"] = *zone.Longitud
+		}
+		rows = append(rows, row)
+	}
+
+	changes := []models.PropertyChange{}
+	err := writeBatches(ctx, r.Driver, "ZoneRepository.UpsertZones", query, rows, batchSize, func(data map[string]any) {
+		change := models.PropertyChange{}
+		change.Zone, _ = data["zona"].(string)
+		if before, ok := data["before"].(map[string]interface{}); ok {
+			change.Before = before
+		}
+		change.After = data["after"]
+		changes = append(changes, change)
+	})
+	if err != nil {
+		return changes, fmt.Errorf("error importing zones: %w", err)
+	}
+	return changes, nil
+}
+
+// Zonas con las propiedades que FindAll y GetAllAsGraph esperan, para la validación de integridad
+func (r *ZoneRepository) FindZoneRecords(ctx context.Context) ([]models.ZoneRecord, error) {
+	query := `MATCH (z:Zona)
+	RETURN ID(z) AS id,
+	z.nombre AS nombre,
+	z.tipo_zona AS tipo,
+	'CentroDistribucion' IN labels(z) AS centro
+	ORDER BY id`
+
+	session := newSession(ctx, r.Driver, "ZoneRepository.FindZoneRecords")
+	defer session.Close()
+
+	t, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		result, err := tx.Run(query, nil)
+		if err != nil {
+			return nil, err
+		}
+		records := []models.ZoneRecord{}
+
+		for result.Next() {
+			data := result.Record().AsMap()
+			record := models.ZoneRecord{}
+			record.ID, _ = data["id"].(int64)
+			record.Centro, _ = data["centro"].(bool)
+			if val, ok := data["nombre"].(string); ok {
+				record.Nombre = val
+			} else {
+				record.Missing = append(record.Missing, "nombre")
+			}
+			if _, ok := data["tipo"].(string); !ok {
+				record.Missing = append(record.Missing, "tipo_zona")
+			}
+			records = append(records, record)
+		}
+		return records, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching zone records: %w", err)
+	}
+	return t.([]models.ZoneRecord), nil
+}
+
+// Cantidad de zonas y de tramos entre zonas, separando los cerrados
+func (r *ZoneRepository) CountNetwork(ctx context.Context) (models.NetworkSize, error) {
+	query := `MATCH (z:Zona)
+	WITH count(z) AS zonas
+	OPTIONAL MATCH (:Zona)-[c:CONECTA]->(:Zona)
+	RETURN zonas,
+	count(c) AS tramos,
+	count(CASE WHEN c.accesible = false THEN 1 END) AS cerrados`
+
+	session := newSession(ctx, r.Driver, "ZoneRepository.CountNetwork")
+	defer session.Close()
+
+	t, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		result, err := tx.Run(query, nil)
+		if err != nil {
+			return nil, err
+		}
+		record, err := result.Single()
+		if err != nil {
+			return nil, err
+		}
+		data := record.AsMap()
+		zones, _ := data["zonas"].(int64)
+		segments, _ := data["tramos"].(int64)
+		closed, _ := data["cerrados"].(int64)
+		return models.NetworkSize{Zones: int(zones), Segments: int(segments), ClosedSegments: int(closed)}, nil
+	})
+	if err != nil {
+		return models.NetworkSize{}, fmt.Errorf("error counting network: %w", err)
+	}
+	return t.(models.NetworkSize), nil
+}
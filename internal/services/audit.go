@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"neo4j_delivery/internal/auth"
+	"neo4j_delivery/internal/models"
+	"neo4j_delivery/internal/tracing"
+)
+
+// Actor y rol de la petición; sin principal en el contexto se registra como "system"
+func actorFrom(ctx context.Context) (string, string) {
+	principal, ok := auth.FromContext(ctx)
+	if !ok || principal.Subject == "" {
+		return "system", ""
+	}
+	return principal.Subject, principal.Role
+}
+
+func auditJSON(value interface{}) json.RawMessage {
+	if value == nil {
+		return json.RawMessage("null")
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return json.RawMessage("null")
+	}
+	return data
+}
+
+// Guarda las entradas de auditoría. La modificación ya se aplicó, así que un fallo solo se registra en el log
+func (s *DeliveryService) recordAudit(ctx context.Context, action, entity string, changes []models.PropertyChange) {
+	if s.AuditRepo == nil || len(changes) == 0 {
+		return
+	}
+	actor, role := actorFrom(ctx)
+	now := time.Now().UTC()
+
+	entries := make([]models.AuditEntry, 0, len(changes))
+	for _, change := range changes {
+		entry := models.AuditEntry{
+			Actor:     actor,
+			Role:      role,
+			Action:    action,
+			Entity:    entity,
+			Zone:      change.Zone,
+			Source:    change.Source,
+			Target:    change.Target,
+			Timestamp: now,
+			Before:    auditJSON(change.Before),
+			After:     auditJSON(change.After),
+		}
+		entries = append(entries, entry)
+	}
+	if err := s.AuditRepo.Save(ctx, entries); err != nil {
+		slog.ErrorContext(ctx, "could not record audit", "action", action, "changes", len(entries), "err", err)
+	}
+}
+
+// Historial de cambios de una zona o de un tramo, el más reciente primero
+func (s *DeliveryService) GetAuditHistory(ctx context.Context, query models.AuditQuery) ([]models.AuditEntry, error) {
+	ctx, span := tracing.Start(ctx, "DeliveryService.GetAuditHistory")
+	defer span.End()
+	return s.AuditRepo.FindHistory(ctx, query)
+}
@@ -2,39 +2,80 @@ package services
 
 import (
 	"context"
-	"log"
+	"errors"
+	"fmt"
 	"neo4j_delivery/internal/dijkstra"
+	"neo4j_delivery/internal/events"
+	"neo4j_delivery/internal/geo"
 	"neo4j_delivery/internal/models"
-	"neo4j_delivery/internal/repositories"
+	"neo4j_delivery/internal/tracing"
+	"slices"
+	"sort"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var ErrZoneNotFound = errors.New("zone not found")
+
 type DeliveryService struct {
-	ZoneRepo  *repositories.ZoneRepository
-	RouteRepo *repositories.RouteRepository
+	ZoneRepo     ZoneStore
+	RouteRepo    RouteStore
+	FleetRepo    FleetStore
+	AuditRepo    AuditStore
+	SnapshotRepo SnapshotStore
+	Events       *events.Broker
+
+	// Cota superior de velocidad para la heurística de A*
+	MaxSpeedKmh float64
+	// Tiempo que se reutiliza el grafo leído de Neo4j; 0 lo lee en cada consulta. Solo las
+	// escrituras de este proceso invalidan la caché antes de que venza
+	GraphCacheTTL time.Duration
+	// Distancia máxima al centroide más cercano para ubicar un punto fuera de todo polígono; 0 sin límite
+	LocateMaxKm float64
+
+	graphCache graphCache
 }
 
-func (s *DeliveryService) GetGraphData() (models.GraphData, error) {
+func (s *DeliveryService) GetGraphData(ctx context.Context) (models.GraphData, error) {
+	ctx, span := tracing.Start(ctx, "DeliveryService.GetGraphData")
+	defer span.End()
 	// Implementa la lógica para obtener nodos y relaciones de Neo4j
-	return s.ZoneRepo.GetGraphData()
+	return s.ZoneRepo.GetGraphData(ctx)
 }
 
-func NewDeliveryService(ZoneRepo *repositories.ZoneRepository) *DeliveryService {
+func NewDeliveryService(ZoneRepo ZoneStore) *DeliveryService {
 	return &DeliveryService{ZoneRepo: ZoneRepo}
 }
 
+// Span para un cálculo del paquete dijkstra, que trabaja en memoria y no recibe contexto
+func computeSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) trace.Span {
+	_, span := tracing.Start(ctx, "dijkstra."+name, attrs...)
+	return span
+}
+
 func (s *DeliveryService) GetAllZones(ctx context.Context) ([]models.Zone, error) {
+	ctx, span := tracing.Start(ctx, "DeliveryService.GetAllZones")
+	defer span.End()
 	return s.ZoneRepo.FindAll(ctx)
 }
 
 func (s *DeliveryService) CalculateRoute(ctx context.Context, from, to string) ([]models.Connection, error) {
+	ctx, span := tracing.Start(ctx, "DeliveryService.CalculateRoute")
+	defer span.End()
 	return s.ZoneRepo.FindOptimalRoute(ctx, from, to)
 }
-func (s *DeliveryService) FindShortestPath(start string, end string) ([]string, float64, error) {
-	g, err := s.ZoneRepo.GetAllAsGraph()
+func (s *DeliveryService) FindShortestPath(ctx context.Context, start string, end string) ([]string, float64, error) {
+	ctx, span := tracing.Start(ctx, "DeliveryService.FindShortestPath")
+	defer span.End()
+	g, err := s.networkGraph(ctx)
 	if err != nil {
 		return nil, -1, err
 	}
+	compute := computeSpan(ctx, "Dijkstra", attribute.String("dijkstra.start", start))
 	table := dijkstra.Dijkstra(g, start)
+	compute.End()
 	path, cost, err := dijkstra.Travel(table, start, end)
 	if err != nil {
 		return nil, -1, err
@@ -43,22 +84,30 @@ func (s *DeliveryService) FindShortestPath(start string, end string) ([]string,
 	return path, cost, nil
 }
 
-func (s *DeliveryService) FindInaccesible(start string) ([]string, []string) {
-	g, err := s.ZoneRepo.GetAllAsGraph()
+func (s *DeliveryService) FindInaccesible(ctx context.Context, start string) ([]string, []string) {
+	ctx, span := tracing.Start(ctx, "DeliveryService.FindInaccesible")
+	defer span.End()
+	g, err := s.networkGraph(ctx)
 	if err != nil {
 		return nil, nil
 	}
+	compute := computeSpan(ctx, "FindInaccessibleNodes", attribute.String("dijkstra.start", start))
 	accesibleNodes, innaccesibleNodes := dijkstra.FindInaccessibleNodes(g, start)
+	compute.End()
 	return accesibleNodes, innaccesibleNodes
 }
 
-func (s *DeliveryService) FindDirectAccessible(start string, minutes float64) map[string][]models.Route {
-	g, err := s.ZoneRepo.GetAllAsGraph()
+func (s *DeliveryService) FindDirectAccessible(ctx context.Context, start string, minutes float64) map[string][]models.Route {
+	ctx, span := tracing.Start(ctx, "DeliveryService.FindDirectAccessible")
+	defer span.End()
+	g, err := s.networkGraph(ctx)
 	if err != nil {
 		return nil
 	}
+	compute := computeSpan(ctx, "Dijkstra", attribute.String("dijkstra.start", start))
 	accesibleNodes, _ := dijkstra.FindInaccessibleNodes(g, start)
 	table := dijkstra.Dijkstra(g, start)
+	compute.End()
 	result := make(map[string][]models.Route)
 
 	for i := range accesibleNodes {
@@ -71,8 +120,213 @@ func (s *DeliveryService) FindDirectAccessible(start string, minutes float64) ma
 	return result
 }
 
-func (s *DeliveryService) GetHighTrafficRoutes() []models.Connection {
-	routes := s.RouteRepo.GetHighTrafficEdges()
-	log.Println(routes)
-	return routes
+// Tramos con tráfico alto, equivalente a QuerySegments con trafico=alto
+func (s *DeliveryService) GetHighTrafficRoutes(ctx context.Context) ([]models.Connection, error) {
+	ctx, span := tracing.Start(ctx, "DeliveryService.GetHighTrafficRoutes")
+	defer span.End()
+	result, err := s.QuerySegments(ctx, models.SegmentQuery{Trafico: []string{"alto"}})
+	return result.Items, err
+}
+
+func (s *DeliveryService) AnalyzeConnectivity(ctx context.Context) (models.ConnectivityReport, error) {
+	ctx, span := tracing.Start(ctx, "DeliveryService.AnalyzeConnectivity")
+	defer span.End()
+	g, err := s.networkGraph(ctx)
+	if err != nil {
+		return models.ConnectivityReport{}, err
+	}
+	centers, err := s.centerNames(ctx)
+	if err != nil {
+		return models.ConnectivityReport{}, err
+	}
+
+	compute := computeSpan(ctx, "StronglyConnectedComponents")
+	defer compute.End()
+	components := dijkstra.StronglyConnectedComponents(g)
+	return models.ConnectivityReport{
+		Components:       components,
+		OneWayTraps:      dijkstra.FindOneWayTraps(g, components),
+		NoReturnToCenter: dijkstra.FindNodesWithoutReturn(g, centers),
+	}, nil
+}
+
+func (s *DeliveryService) centerNames(ctx context.Context) ([]string, error) {
+	centers, err := s.ZoneRepo.GetDistributionCenters(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(centers))
+	for _, center := range centers {
+		names = append(names, center.Nombre)
+	}
+	return names, nil
+}
+
+// Ordena los tramos y zonas críticas según las zonas o la población que dejarían aisladas
+func (s *DeliveryService) FindCriticalElements(ctx context.Context, rankBy string) (models.CriticalityReport, error) {
+	ctx, span := tracing.Start(ctx, "DeliveryService.FindCriticalElements")
+	defer span.End()
+	g, err := s.networkGraph(ctx)
+	if err != nil {
+		return models.CriticalityReport{}, err
+	}
+	centers, err := s.centerNames(ctx)
+	if err != nil {
+		return models.CriticalityReport{}, err
+	}
+	zones, err := s.ZoneRepo.FindAll(ctx)
+	if err != nil {
+		return models.CriticalityReport{}, err
+	}
+	population := make(map[string]int)
+	for _, zone := range zones {
+		if zone.Poblacion != nil {
+			population[zone.Nombre] = *zone.Poblacion
+		}
+	}
+	sumPopulation := func(names []string) int {
+		total := 0
+		for _, name := range names {
+			total += population[name]
+		}
+		return total
+	}
+
+	compute := computeSpan(ctx, "FindCriticalSegments")
+	segments := dijkstra.FindCriticalSegments(g, centers)
+	compute.End()
+	for i := range segments {
+		segments[i].PopulationCutOff = sumPopulation(segments[i].CutOffZones)
+	}
+	compute = computeSpan(ctx, "FindCriticalZones")
+	criticalZones := dijkstra.FindCriticalZones(g, centers)
+	compute.End()
+	for i := range criticalZones {
+		criticalZones[i].PopulationCutOff = sumPopulation(criticalZones[i].CutOffZones)
+	}
+
+	byPopulation := rankBy == "population"
+	sort.SliceStable(segments, func(i, j int) bool {
+		if byPopulation && segments[i].PopulationCutOff != segments[j].PopulationCutOff {
+			return segments[i].PopulationCutOff > segments[j].PopulationCutOff
+		}
+		if len(segments[i].CutOffZones) != len(segments[j].CutOffZones) {
+			return len(segments[i].CutOffZones) > len(segments[j].CutOffZones)
+		}
+		if segments[i].Source != segments[j].Source {
+			return segments[i].Source < segments[j].Source
+		}
+		return segments[i].Target < segments[j].Target
+	})
+	sort.SliceStable(criticalZones, func(i, j int) bool {
+		if byPopulation && criticalZones[i].PopulationCutOff != criticalZones[j].PopulationCutOff {
+			return criticalZones[i].PopulationCutOff > criticalZones[j].PopulationCutOff
+		}
+		if len(criticalZones[i].CutOffZones) != len(criticalZones[j].CutOffZones) {
+			return len(criticalZones[i].CutOffZones) > len(criticalZones[j].CutOffZones)
+		}
+		return criticalZones[i].Zone < criticalZones[j].Zone
+	})
+
+	return models.CriticalityReport{Segments: segments, Zones: criticalZones}, nil
+}
+
+// Calcula las centralidades ordenadas de mayor a menor intermediación y opcionalmente las persiste
+func (s *DeliveryService) RankCentrality(ctx context.Context, persist bool) (models.CentralityReport, error) {
+	ctx, span := tracing.Start(ctx, "DeliveryService.RankCentrality")
+	defer span.End()
+	g, err := s.networkGraph(ctx)
+	if err != nil {
+		return models.CentralityReport{}, err
+	}
+	compute := computeSpan(ctx, "Centrality")
+	zones, segments := dijkstra.Centrality(g)
+	compute.End()
+
+	if persist {
+		if err := s.ZoneRepo.SaveZoneCentrality(ctx, zones); err != nil {
+			return models.CentralityReport{}, err
+		}
+		if err := s.RouteRepo.SaveSegmentCentrality(ctx, segments); err != nil {
+			return models.CentralityReport{}, err
+		}
+	}
+
+	sort.SliceStable(zones, func(i, j int) bool {
+		if zones[i].Betweenness != zones[j].Betweenness {
+			return zones[i].Betweenness > zones[j].Betweenness
+		}
+		return zones[i].Closeness > zones[j].Closeness
+	})
+	sort.SliceStable(segments, func(i, j int) bool {
+		return segments[i].Betweenness > segments[j].Betweenness
+	})
+	return models.CentralityReport{Zones: zones, Segments: segments}, nil
+}
+
+// Calcula cuántos vehículos por periodo pueden llegar a la zona destino.
+// Si no se indica un centro de origen se usan todos los centros de distribución
+func (s *DeliveryService) ComputeThroughput(ctx context.Context, from, to string) (models.FlowResult, error) {
+	ctx, span := tracing.Start(ctx, "DeliveryService.ComputeThroughput")
+	defer span.End()
+	connections, err := s.RouteRepo.GetAllConnections(ctx)
+	if err != nil {
+		return models.FlowResult{}, err
+	}
+	centers, err := s.centerNames(ctx)
+	if err != nil {
+		return models.FlowResult{}, err
+	}
+
+	sources := centers
+	if from != "" {
+		if !slices.Contains(centers, from) {
+			return models.FlowResult{}, fmt.Errorf("%w: %q is not a distribution center", ErrZoneNotFound, from)
+		}
+		sources = []string{from}
+	}
+	known := false
+	for _, connection := range connections {
+		if connection.Source == to || connection.Target == to {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return models.FlowResult{}, fmt.Errorf("%w: %q", ErrZoneNotFound, to)
+	}
+
+	compute := computeSpan(ctx, "MaxFlow")
+	flow, cut := dijkstra.MaxFlow(connections, sources, to)
+	compute.End()
+	return models.FlowResult{From: sources, To: to, MaxFlow: flow, Bottleneck: cut}, nil
+}
+
+// Igual que FindShortestPath pero con A*, usando las coordenadas de las zonas como heurística
+func (s *DeliveryService) FindShortestPathAStar(ctx context.Context, start string, end string) ([]string, float64, error) {
+	ctx, span := tracing.Start(ctx, "DeliveryService.FindShortestPathAStar")
+	defer span.End()
+	g, err := s.networkGraph(ctx)
+	if err != nil {
+		return nil, -1, err
+	}
+	zones, err := s.ZoneRepo.FindAll(ctx)
+	if err != nil {
+		return nil, -1, err
+	}
+	coordinates := make(map[string]geo.Point)
+	for _, zone := range zones {
+		if zone.Latitud != nil && zone.Longitud != nil {
+			coordinates[zone.Nombre] = geo.Point{Lat: *zone.Latitud, Lng: *zone.Longitud}
+		}
+	}
+
+	heuristic := dijkstra.TimeHeuristic(g, coordinates, s.MaxSpeedKmh, end)
+	compute := computeSpan(ctx, "AStar", attribute.String("dijkstra.start", start))
+	path, cost, err := dijkstra.AStar(g, start, end, heuristic)
+	compute.End()
+	if err != nil {
+		return nil, -1, err
+	}
+	return path, cost, nil
 }
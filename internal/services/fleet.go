@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"neo4j_delivery/internal/events"
+	"neo4j_delivery/internal/models"
+	"neo4j_delivery/internal/tracing"
+)
+
+var ErrInvalidFleet = errors.New("invalid fleet data")
+
+var vehicleStates = []string{
+	models.VehiculoDisponible,
+	models.VehiculoEnRuta,
+	models.VehiculoMantenimiento,
+	models.VehiculoInactivo,
+}
+
+func validateVehicle(vehicle *models.Vehiculo) error {
+	if vehicle.Placa == "" || vehicle.Centro == "" {
+		return fmt.Errorf("%w: placa and centro are required", ErrInvalidFleet)
+	}
+	if vehicle.CapacidadCarga <= 0 {
+		return fmt.Errorf("%w: capacidad_carga must be positive", ErrInvalidFleet)
+	}
+	if vehicle.Estado == "" {
+		vehicle.Estado = models.VehiculoDisponible
+	}
+	if !slices.Contains(vehicleStates, vehicle.Estado) {
+		return fmt.Errorf("%w: unknown estado %q", ErrInvalidFleet, vehicle.Estado)
+	}
+	return nil
+}
+
+func validateDriver(driver *models.Conductor) error {
+	if driver.Nombre == "" || driver.Centro == "" {
+		return fmt.Errorf("%w: nombre and centro are required", ErrInvalidFleet)
+	}
+	start, err := parseClock(driver.TurnoInicio)
+	if err != nil {
+		return fmt.Errorf("%w: turno_inicio %q must be HH:MM", ErrInvalidFleet, driver.TurnoInicio)
+	}
+	end, err := parseClock(driver.TurnoFin)
+	if err != nil {
+		return fmt.Errorf("%w: turno_fin %q must be HH:MM", ErrInvalidFleet, driver.TurnoFin)
+	}
+	if start == end {
+		return fmt.Errorf("%w: shift cannot be empty", ErrInvalidFleet)
+	}
+	return nil
+}
+
+func (s *DeliveryService) ListVehicles(ctx context.Context, center string) ([]models.Vehiculo, error) {
+	ctx, span := tracing.Start(ctx, "DeliveryService.ListVehicles")
+	defer span.End()
+	return s.FleetRepo.FindVehicles(ctx, center)
+}
+
+func (s *DeliveryService) GetVehicle(ctx context.Context, id string) (models.Vehiculo, error) {
+	ctx, span := tracing.Start(ctx, "DeliveryService.GetVehicle")
+	defer span.End()
+	return s.FleetRepo.FindVehicle(ctx, id)
+}
+
+func (s *DeliveryService) CreateVehicle(ctx context.Context, vehicle models.Vehiculo) (models.Vehiculo, error) {
+	ctx, span := tracing.Start(ctx, "DeliveryService.CreateVehicle")
+	defer span.End()
+	if err := validateVehicle(&vehicle); err != nil {
+		return models.Vehiculo{}, err
+	}
+	saved, err := s.FleetRepo.CreateVehicle(ctx, vehicle)
+	if err != nil {
+		return models.Vehiculo{}, err
+	}
+	s.Events.Publish(events.VehicleUpdated, []string{saved.Centro}, saved)
+	return saved, nil
+}
+
+func (s *DeliveryService) UpdateVehicle(ctx context.Context, vehicle models.Vehiculo) (models.Vehiculo, error) {
+	ctx, span := tracing.Start(ctx, "DeliveryService.UpdateVehicle")
+	defer span.End()
+	if err := validateVehicle(&vehicle); err != nil {
+		return models.Vehiculo{}, err
+	}
+	saved, err := s.FleetRepo.UpdateVehicle(ctx, vehicle)
+	if err != nil {
+		return models.Vehiculo{}, err
+	}
+	s.Events.Publish(events.VehicleUpdated, []string{saved.Centro}, saved)
+	return saved, nil
+}
+
+func (s *DeliveryService) DeleteVehicle(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "DeliveryService.DeleteVehicle")
+	defer span.End()
+	vehicle, err := s.FleetRepo.FindVehicle(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.FleetRepo.DeleteVehicle(ctx, id); err != nil {
+		return err
+	}
+	s.Events.Publish(events.VehicleUpdated, []string{vehicle.Centro}, map[string]interface{}{"id": id, "deleted": true})
+	return nil
+}
+
+func (s *DeliveryService) ListDrivers(ctx context.Context, center string) ([]models.Conductor, error) {
+	ctx, span := tracing.Start(ctx, "DeliveryService.ListDrivers")
+	defer span.End()
+	return s.FleetRepo.FindDrivers(ctx, center)
+}
+
+func (s *DeliveryService) GetDriver(ctx context.Context, id string) (models.Conductor, error) {
+	ctx, span := tracing.Start(ctx, "DeliveryService.GetDriver")
+	defer span.End()
+	return s.FleetRepo.FindDriver(ctx, id)
+}
+
+func (s *DeliveryService) CreateDriver(ctx context.Context, driver models.Conductor) (models.Conductor, error) {
+	ctx, span := tracing.Start(ctx, "DeliveryService.CreateDriver")
+	defer span.End()
+	if err := validateDriver(&driver); err != nil {
+		return models.Conductor{}, err
+	}
+	return s.FleetRepo.CreateDriver(ctx, driver)
+}
+
+func (s *DeliveryService) UpdateDriver(ctx context.Context, driver models.Conductor) (models.Conductor, error) {
+	ctx, span := tracing.Start(ctx, "DeliveryService.UpdateDriver")
+	defer span.End()
+	if err := validateDriver(&driver); err != nil {
+		return models.Conductor{}, err
+	}
+	return s.FleetRepo.UpdateDriver(ctx, driver)
+}
+
+func (s *DeliveryService) DeleteDriver(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "DeliveryService.DeleteDriver")
+	defer span.End()
+	return s.FleetRepo.DeleteDriver(ctx, id)
+}
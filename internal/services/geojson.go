@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"neo4j_delivery/internal/models"
+	"neo4j_delivery/internal/tracing"
+)
+
+// Exporta zonas, conexiones y opcionalmente la ruta más corta entre start y end como GeoJSON.
+// Las zonas sin coordenadas se omiten, igual que las conexiones que las tocan
+func (s *DeliveryService) ExportGeoJSON(ctx context.Context, start, end string) (models.FeatureCollection, error) {
+	ctx, span := tracing.Start(ctx, "DeliveryService.ExportGeoJSON")
+	defer span.End()
+	graphData, err := s.ZoneRepo.GetGraphData(ctx)
+	if err != nil {
+		return models.FeatureCollection{}, err
+	}
+
+	collection := models.NewFeatureCollection()
+	byID := make(map[string]models.Node)
+	byName := make(map[string]models.Node)
+
+	for _, node := range graphData.Nodes {
+		if node.Lat == nil || node.Lng == nil {
+			continue
+		}
+		byID[node.ID] = node
+		byName[node.Name] = node
+		properties := map[string]interface{}{"kind": "zone", "name": node.Name, "label": node.Label, "tipo": node.Tipo}
+		collection.Features = append(collection.Features, models.PointFeature(*node.Lng, *node.Lat, properties))
+		if len(node.Boundary) > 0 {
+			collection.Features = append(collection.Features, models.PolygonFeature(node.Boundary, map[string]interface{}{"kind": "zone_boundary", "name": node.Name}))
+		}
+	}
+
+	for _, link := range graphData.Links {
+		source, okSource := byID[link.Source]
+		target, okTarget := byID[link.Target]
+		if !okSource || !okTarget {
+			continue
+		}
+		properties := map[string]interface{}{
+			"kind":           "connection",
+			"source":         source.Name,
+			"target":         target.Name,
+			"tiempo_minutos": link.Tiempo_minutos,
+			"trafico_actual": link.Trafico_actual,
+			"capacidad":      link.Capacidad,
+			"accesible":      link.Accesible,
+		}
+		coordinates := [][2]float64{{*source.Lng, *source.Lat}, {*target.Lng, *target.Lat}}
+		collection.Features = append(collection.Features, models.LineFeature(coordinates, properties))
+	}
+
+	if start != "" && end != "" {
+		path, cost, err := s.FindShortestPath(ctx, start, end)
+		if err != nil {
+			return models.FeatureCollection{}, fmt.Errorf("%w: %v", ErrZoneNotFound, err)
+		}
+		coordinates := [][2]float64{}
+		for _, name := range path {
+			if node, ok := byName[name]; ok {
+				coordinates = append(coordinates, [2]float64{*node.Lng, *node.Lat})
+			}
+		}
+		properties := map[string]interface{}{"kind": "route", "path": path, "minutes": cost}
+		collection.Features = append(collection.Features, models.LineFeature(coordinates, properties))
+	}
+	return collection, nil
+}
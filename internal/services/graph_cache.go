@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"neo4j_delivery/internal/dijkstra"
+	"neo4j_delivery/internal/metrics"
+	"neo4j_delivery/internal/models"
+)
+
+// La tasa de aciertos es hit / (hit + miss)
+var graphCacheRequests = metrics.NewCounterVec("delivery_graph_cache_requests_total",
+	"Lecturas del grafo de la red servidas desde la caché (hit) o desde Neo4j (miss).", "result")
+
+func init() {
+	graphCacheRequests.Add(0, "hit")
+	graphCacheRequests.Add(0, "miss")
+}
+
+// Último grafo leído de Neo4j, compartido por los algoritmos de rutas
+type graphCache struct {
+	mu     sync.Mutex
+	graph  models.Graph
+	loaded time.Time
+	// Lectura de Neo4j en curso; las consultas que llegan mientras tanto la esperan en vez de repetirla
+	loading *graphLoad
+	// Aumenta con cada invalidación, para no guardar un grafo leído antes de un cambio
+	generation uint64
+}
+
+type graphLoad struct {
+	done       chan struct{}
+	generation uint64
+	graph      models.Graph
+	err        error
+}
+
+// Grafo de la red para los algoritmos. Se reutiliza durante GraphCacheTTL o hasta que un
+// cambio hecho por el servicio lo invalide. Los cambios hechos fuera de este proceso no la
+// invalidan y se ven con hasta GraphCacheTTL de retraso; con el TTL en 0 se lee siempre de Neo4j.
+// El mutex no se mantiene durante la lectura de Neo4j, así un fallo de caché no bloquea las
+// consultas que ya tienen grafo. Cada llamada recibe una copia, así quien la use puede modificarla
+func (s *DeliveryService) networkGraph(ctx context.Context) (models.Graph, error) {
+	s.graphCache.mu.Lock()
+	if s.graphCache.graph != nil && time.Since(s.graphCache.loaded) < s.GraphCacheTTL {
+		g := s.graphCache.graph
+		s.graphCache.mu.Unlock()
+		graphCacheRequests.Inc("hit")
+		return dijkstra.CopyGraph(g), nil
+	}
+	graphCacheRequests.Inc("miss")
+	// Una lectura empezada antes de la última invalidación no sirve: podría no ver ese cambio
+	load := s.graphCache.loading
+	if load != nil && load.generation == s.graphCache.generation {
+		s.graphCache.mu.Unlock()
+		select {
+		case <-load.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	} else {
+		load = &graphLoad{done: make(chan struct{}), generation: s.graphCache.generation}
+		s.graphCache.loading = load
+		s.graphCache.mu.Unlock()
+
+		// La lectura la comparten otras consultas, así que no se cancela con esta
+		load.graph, load.err = s.ZoneRepo.GetAllAsGraph(context.WithoutCancel(ctx))
+
+		s.graphCache.mu.Lock()
+		if s.graphCache.loading == load {
+			s.graphCache.loading = nil
+		}
+		if load.err == nil && load.generation == s.graphCache.generation {
+			s.graphCache.graph, s.graphCache.loaded = load.graph, time.Now()
+		}
+		s.graphCache.mu.Unlock()
+		close(load.done)
+	}
+	if load.err != nil {
+		return nil, load.err
+	}
+	return dijkstra.CopyGraph(load.graph), nil
+}
+
+func (s *DeliveryService) invalidateGraph() {
+	s.graphCache.mu.Lock()
+	s.graphCache.graph = nil
+	s.graphCache.generation++
+	s.graphCache.mu.Unlock()
+}
+
+var (
+	graphZones = metrics.NewGaugeVec("delivery_graph_zones",
+		"Zonas de la red de reparto.")
+	graphSegments = metrics.NewGaugeVec("delivery_graph_segments",
+		"Tramos de la red de reparto según estén abiertos o cerrados.", "state")
+)
+
+// Actualiza el tamaño de la red antes de cada lectura de /metrics. Se consulta Neo4j
+// directamente para no contar estas lecturas en la tasa de aciertos de la caché
+func (s *DeliveryService) CollectMetrics() {
+	size, err := s.ZoneRepo.CountNetwork(context.Background())
+	if err != nil {
+		slog.Warn("could not collect network size", "err", err)
+		return
+	}
+	graphZones.Set(float64(size.Zones))
+	graphSegments.Set(float64(size.Segments-size.ClosedSegments), "open")
+	graphSegments.Set(float64(size.ClosedSegments), "closed")
+}
+
+// Carga el grafo si la caché todavía no lo pudo leer nunca. /readyz lo usa para saber
+// si las rutas ya tienen una red con la que trabajar
+func (s *DeliveryService) EnsureGraphLoaded(ctx context.Context) error {
+	s.graphCache.mu.Lock()
+	loaded := !s.graphCache.loaded.IsZero()
+	s.graphCache.mu.Unlock()
+	if loaded {
+		return nil
+	}
+	_, err := s.networkGraph(ctx)
+	return err
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+
+	"neo4j_delivery/internal/events"
+	"neo4j_delivery/internal/importer"
+	"neo4j_delivery/internal/models"
+	"neo4j_delivery/internal/tracing"
+	"neo4j_delivery/internal/validation"
+)
+
+// Valida e importa zonas y tramos en bloque. Antes de escribir se valida la red resultante y
+// se rechaza la importación si introduce errores de integridad (o advertencias con Strict).
+// Si hay errores o es dry-run no se escribe nada y el reporte indica qué se habría creado
+func (s *DeliveryService) ImportNetwork(ctx context.Context, data models.ImportData, options models.ImportOptions) (models.ImportReport, error) {
+	ctx, span := tracing.Start(ctx, "DeliveryService.ImportNetwork")
+	defer span.End()
+	report := models.ImportReport{
+		DryRun:      options.DryRun,
+		Zones:       len(data.Zones),
+		Connections: len(data.Connections),
+		NewZones:    []string{},
+		Validation:  []models.ValidationIssue{},
+	}
+
+	zones, segments, err := s.networkRecords(ctx)
+	if err != nil {
+		return report, err
+	}
+	existing := make(map[string]bool, len(zones))
+	for _, zone := range zones {
+		if zone.Nombre != "" {
+			existing[zone.Nombre] = true
+		}
+	}
+	for _, zone := range data.Zones {
+		if zone.Nombre != "" && !existing[zone.Nombre] {
+			report.NewZones = append(report.NewZones, zone.Nombre)
+		}
+	}
+
+	report.Errors = importer.Validate(data, existing)
+	if len(report.Errors) > 0 {
+		return report, nil
+	}
+
+	// Solo cuentan las incidencias nuevas para no bloquear importaciones por problemas previos
+	mergedZones, mergedSegments := mergeImport(zones, segments, data)
+	report.Validation = validation.NewIssues(validation.Check(zones, segments), validation.Check(mergedZones, mergedSegments))
+	for _, issue := range report.Validation {
+		if issue.Severity == validation.SeverityError || options.Strict {
+			report.Errors = append(report.Errors, models.ImportIssue{Kind: "network", Message: issue.Message})
+		}
+	}
+	if len(report.Errors) > 0 || options.DryRun {
+		return report, nil
+	}
+
+	// Respaldo para poder deshacer la importación con RestoreSnapshot
+	backup, err := s.CreateSnapshot(ctx, "before import")
+	if err != nil {
+		return report, err
+	}
+	report.BackupVersion = backup.Version
+	// Aunque falle un lote, los anteriores ya quedaron escritos
+	defer s.invalidateGraph()
+
+	zoneChanges, err := s.ZoneRepo.UpsertZones(ctx, data.Zones, options.BatchSize)
+	s.recordAudit(ctx, models.AuditActionImportZone, models.AuditEntityZone, zoneChanges)
+	if err != nil {
+		return report, err
+	}
+	connectionChanges, err := s.RouteRepo.UpsertConnections(ctx, data.Connections, options.BatchSize)
+	s.recordAudit(ctx, models.AuditActionImportConnection, models.AuditEntitySegment, connectionChanges)
+	if err != nil {
+		return report, err
+	}
+	report.Applied = true
+
+	for _, zone := range data.Zones {
+		s.Events.Publish(events.ZoneUpdated, []string{zone.Nombre}, zone)
+	}
+	for _, connection := range data.Connections {
+		s.Events.Publish(events.ConnectionUpdated, []string{connection.Source, connection.Target}, connection)
+	}
+	return report, nil
+}
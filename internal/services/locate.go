@@ -0,0 +1,48 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"neo4j_delivery/internal/geo"
+	"neo4j_delivery/internal/models"
+	"neo4j_delivery/internal/tracing"
+)
+
+// Construye el índice espacial con los polígonos y centroides de las zonas
+func newZoneIndex(zones []models.Zone) *geo.Index {
+	regions := make([]geo.Region, 0, len(zones))
+	for _, zone := range zones {
+		region := geo.Region{Name: zone.Nombre, Boundary: zone.Limite}
+		if zone.Latitud != nil && zone.Longitud != nil {
+			region.Centroid = &geo.Point{Lat: *zone.Latitud, Lng: *zone.Longitud}
+		}
+		regions = append(regions, region)
+	}
+	return geo.NewIndex(regions)
+}
+
+// Ubica la zona que corresponde a unas coordenadas GPS
+func (s *DeliveryService) LocateZone(ctx context.Context, lat, lng float64) (models.ZoneLocation, error) {
+	ctx, span := tracing.Start(ctx, "DeliveryService.LocateZone")
+	defer span.End()
+	zones, err := s.ZoneRepo.FindAll(ctx)
+	if err != nil {
+		return models.ZoneLocation{}, err
+	}
+	return s.locate(newZoneIndex(zones), geo.Point{Lat: lat, Lng: lng})
+}
+
+// Ubica el punto en el índice. Si no cae en ningún polígono solo se acepta el centroide más cercano
+// cuando está a menos de LocateMaxKm, para que un punto en cualquier lugar del mundo no caiga en una zona
+func (s *DeliveryService) locate(index *geo.Index, point geo.Point) (models.ZoneLocation, error) {
+	region, method, distance, ok := index.Locate(point)
+	if !ok {
+		return models.ZoneLocation{}, fmt.Errorf("%w: no zone has coordinates", ErrZoneNotFound)
+	}
+	if method == geo.MethodCentroid && s.LocateMaxKm > 0 && distance > s.LocateMaxKm {
+		return models.ZoneLocation{}, fmt.Errorf("%w: nearest zone %q is %.1f km away, beyond the %.1f km limit",
+			ErrZoneNotFound, region.Name, distance, s.LocateMaxKm)
+	}
+	return models.ZoneLocation{Zone: region.Name, Method: method, DistanceKm: distance}, nil
+}
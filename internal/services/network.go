@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"neo4j_delivery/internal/events"
+	"neo4j_delivery/internal/models"
+	"neo4j_delivery/internal/tracing"
+)
+
+var ErrInvalidSegment = errors.New("invalid segment update")
+
+// Modifica tráfico, cierre, tiempo o capacidad de un tramo, lo registra en la auditoría
+// y publica los eventos correspondientes
+func (s *DeliveryService) UpdateSegment(ctx context.Context, source, target string, update models.SegmentUpdate) (models.Connection, error) {
+	ctx, span := tracing.Start(ctx, "DeliveryService.UpdateSegment")
+	defer span.End()
+	if update.Trafico != nil {
+		if _, ok := trafficFactors[*update.Trafico]; !ok {
+			return models.Connection{}, fmt.Errorf("%w: unknown traffic level %q", ErrInvalidSegment, *update.Trafico)
+		}
+	}
+	if update.TiempoMinutos != nil && *update.TiempoMinutos <= 0 {
+		return models.Connection{}, fmt.Errorf("%w: tiempo_minutos must be positive", ErrInvalidSegment)
+	}
+	if update.Capacidad != nil && *update.Capacidad < 0 {
+		return models.Connection{}, fmt.Errorf("%w: capacidad cannot be negative", ErrInvalidSegment)
+	}
+
+	before, after, err := s.RouteRepo.UpdateSegment(ctx, source, target, update)
+	if err != nil {
+		return models.Connection{}, err
+	}
+	s.invalidateGraph()
+	s.recordAudit(ctx, models.AuditActionUpdateSegment, models.AuditEntitySegment, []models.PropertyChange{
+		{Source: after.Source, Target: after.Target, Before: before, After: after},
+	})
+
+	zones := []string{after.Source, after.Target}
+	s.Events.Publish(events.ConnectionUpdated, zones, after)
+	if before.Trafico != after.Trafico {
+		s.Events.Publish(events.TrafficChanged, zones, after)
+	}
+	if before.Accesible && !after.Accesible {
+		s.Events.Publish(events.ClosureStarted, zones, after)
+	} else if !before.Accesible && after.Accesible {
+		s.Events.Publish(events.ClosureEnded, zones, after)
+	}
+	return after, nil
+}
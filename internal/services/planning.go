@@ -0,0 +1,225 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"neo4j_delivery/internal/dijkstra"
+	"neo4j_delivery/internal/geo"
+	"neo4j_delivery/internal/models"
+	"neo4j_delivery/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var ErrInvalidPlan = errors.New("invalid route plan")
+
+const (
+	defaultStartTime = "08:00"
+	endOfDay         = 24 * 60
+)
+
+// Pedido con la ventana ya resuelta en minutos desde medianoche
+type plannedOrder struct {
+	order   models.Order
+	start   float64
+	end     float64
+	service float64
+}
+
+// Convierte "HH:MM" a minutos desde medianoche
+func parseClock(value string) (float64, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid time %q, expected HH:MM", ErrInvalidPlan, value)
+	}
+	return float64(t.Hour()*60 + t.Minute()), nil
+}
+
+// Formatea minutos desde medianoche como "HH:MM". Pasada la medianoche la hora da la vuelta y se
+// agrega "+Nd" con los días transcurridos, para que 24:30 se lea 00:30+1d y no como una hora inválida
+func formatClock(minutes float64) string {
+	total := int(math.Round(minutes))
+	days, rest := total/endOfDay, total%endOfDay
+	clock := fmt.Sprintf("%02d:%02d", rest/60, rest%60)
+	if days > 0 {
+		clock += fmt.Sprintf("+%dd", days)
+	}
+	return clock
+}
+
+// Planifica rutas desde un centro de distribución respetando las ventanas horarias de cada pedido.
+// Usa una heurística de inserción voraz: cada vehículo atiende siempre el pedido factible que
+// termina antes, y los pedidos que ningún vehículo puede atender a tiempo quedan marcados
+func (s *DeliveryService) PlanRoutes(ctx context.Context, req models.PlanRequest) (models.RoutePlan, error) {
+	ctx, span := tracing.Start(ctx, "DeliveryService.PlanRoutes")
+	defer span.End()
+	centers, err := s.centerNames(ctx)
+	if err != nil {
+		return models.RoutePlan{}, err
+	}
+	if !slices.Contains(centers, req.Center) {
+		return models.RoutePlan{}, fmt.Errorf("%w: %q is not a distribution center", ErrInvalidPlan, req.Center)
+	}
+	if req.StartTime == "" {
+		req.StartTime = defaultStartTime
+	}
+	startTime, err := parseClock(req.StartTime)
+	if err != nil {
+		return models.RoutePlan{}, err
+	}
+	if req.Vehicles <= 0 {
+		req.Vehicles = 1
+	}
+
+	zones, err := s.ZoneRepo.FindAll(ctx)
+	if err != nil {
+		return models.RoutePlan{}, err
+	}
+	zonesByName := make(map[string]models.Zone)
+	for _, zone := range zones {
+		zonesByName[zone.Nombre] = zone
+	}
+
+	index := newZoneIndex(zones)
+
+	pending := []plannedOrder{}
+	for _, order := range req.Orders {
+		if order.Zone == "" && order.Lat != nil && order.Lng != nil {
+			location, err := s.locate(index, geo.Point{Lat: *order.Lat, Lng: *order.Lng})
+			if err != nil {
+				return models.RoutePlan{}, fmt.Errorf("%w: order %q: %v", ErrInvalidPlan, order.ID, err)
+			}
+			order.Zone = location.Zone
+		}
+		zone, exists := zonesByName[order.Zone]
+		if !exists {
+			return models.RoutePlan{}, fmt.Errorf("%w: unknown zone %q for order %q", ErrInvalidPlan, order.Zone, order.ID)
+		}
+		if order.WindowStart == "" && order.WindowEnd == "" {
+			order.WindowStart, order.WindowEnd = zone.VentanaInicio, zone.VentanaFin
+		}
+		if order.ServiceMinutes == 0 {
+			order.ServiceMinutes = zone.TiempoServicio
+		}
+		planned := plannedOrder{order: order, start: 0, end: endOfDay, service: order.ServiceMinutes}
+		if order.WindowStart != "" {
+			if planned.start, err = parseClock(order.WindowStart); err != nil {
+				return models.RoutePlan{}, err
+			}
+		}
+		if order.WindowEnd != "" {
+			if planned.end, err = parseClock(order.WindowEnd); err != nil {
+				return models.RoutePlan{}, err
+			}
+		}
+		if planned.end < planned.start {
+			return models.RoutePlan{}, fmt.Errorf("%w: window of order %q ends before it starts", ErrInvalidPlan, order.ID)
+		}
+		pending = append(pending, planned)
+	}
+
+	g, err := s.networkGraph(ctx)
+	if err != nil {
+		return models.RoutePlan{}, err
+	}
+	g = dijkstra.AccessibleSubgraph(g)
+
+	// Tablas de Dijkstra desde el centro y desde cada zona con pedidos
+	compute := computeSpan(ctx, "Dijkstra")
+	tables := map[string]map[string]models.Edge{req.Center: dijkstra.Dijkstra(g, req.Center)}
+	for _, planned := range pending {
+		if _, exists := tables[planned.order.Zone]; !exists {
+			tables[planned.order.Zone] = dijkstra.Dijkstra(g, planned.order.Zone)
+		}
+	}
+	compute.SetAttributes(attribute.Int("dijkstra.runs", len(tables)))
+	compute.End()
+	travel := func(from, to string) float64 {
+		if entry, exists := tables[from][to]; exists {
+			return entry.Cost
+		}
+		return math.Inf(1)
+	}
+
+	plan := models.RoutePlan{Center: req.Center, Routes: []models.VehicleRoute{}, Unserved: []models.UnservedOrder{}}
+
+	// Los pedidos inalcanzables o imposibles incluso saliendo directo del centro se descartan primero
+	feasible := []plannedOrder{}
+	for _, planned := range pending {
+		direct := travel(req.Center, planned.order.Zone)
+		switch {
+		case math.IsInf(direct, 1):
+			plan.Unserved = append(plan.Unserved, models.UnservedOrder{OrderID: planned.order.ID, Zone: planned.order.Zone, Reason: "unreachable"})
+		case startTime+direct > planned.end:
+			plan.Unserved = append(plan.Unserved, models.UnservedOrder{OrderID: planned.order.ID, Zone: planned.order.Zone, Reason: "window_missed"})
+		default:
+			feasible = append(feasible, planned)
+		}
+	}
+
+	for vehicle := 1; vehicle <= req.Vehicles && len(feasible) > 0; vehicle++ {
+		route := models.VehicleRoute{Vehicle: vehicle, Stops: []models.PlannedStop{}}
+		current, clock := req.Center, startTime
+
+		for {
+			best := -1
+			bestFinish := math.Inf(1)
+			for i, planned := range feasible {
+				arrival := clock + travel(current, planned.order.Zone)
+				if arrival > planned.end {
+					continue
+				}
+				finish := math.Max(arrival, planned.start) + planned.service
+				if finish < bestFinish || (finish == bestFinish && planned.end < feasible[best].end) {
+					best, bestFinish = i, finish
+				}
+			}
+			if best < 0 {
+				break
+			}
+
+			planned := feasible[best]
+			travelTime := travel(current, planned.order.Zone)
+			arrival := clock + travelTime
+			serviceStart := math.Max(arrival, planned.start)
+			wait := serviceStart - arrival
+
+			route.Stops = append(route.Stops, models.PlannedStop{
+				OrderID:      planned.order.ID,
+				Zone:         planned.order.Zone,
+				Arrival:      formatClock(arrival),
+				ServiceStart: formatClock(serviceStart),
+				Departure:    formatClock(bestFinish),
+				WaitMinutes:  wait,
+				WindowStart:  planned.order.WindowStart,
+				WindowEnd:    planned.order.WindowEnd,
+			})
+			route.TravelMinutes += travelTime
+			route.WaitMinutes += wait
+			current, clock = planned.order.Zone, bestFinish
+			feasible = append(feasible[:best], feasible[best+1:]...)
+		}
+
+		if len(route.Stops) == 0 {
+			break
+		}
+		// Los tramos de un solo sentido pueden dejar al vehículo sin camino de vuelta al centro
+		if back := travel(current, req.Center); math.IsInf(back, 1) {
+			route.ReturnUnreachable = true
+		} else {
+			route.TravelMinutes += back
+			route.ReturnTime = formatClock(clock + back)
+		}
+		plan.Routes = append(plan.Routes, route)
+	}
+
+	for _, planned := range feasible {
+		plan.Unserved = append(plan.Unserved, models.UnservedOrder{OrderID: planned.order.ID, Zone: planned.order.Zone, Reason: "no_vehicle"})
+	}
+	return plan, nil
+}
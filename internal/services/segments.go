@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"neo4j_delivery/internal/models"
+	"neo4j_delivery/internal/tracing"
+)
+
+var ErrInvalidSegmentQuery = errors.New("invalid segment query")
+
+// Consulta tramos por tráfico, accesibilidad, capacidad y tiempo; con GroupBy agrega además por zona
+func (s *DeliveryService) QuerySegments(ctx context.Context, query models.SegmentQuery) (models.SegmentQueryResult, error) {
+	ctx, span := tracing.Start(ctx, "DeliveryService.QuerySegments")
+	defer span.End()
+	for _, level := range query.Trafico {
+		if _, ok := trafficFactors[level]; !ok {
+			return models.SegmentQueryResult{}, fmt.Errorf("%w: unknown traffic level %q", ErrInvalidSegmentQuery, level)
+		}
+	}
+	switch query.SortBy {
+	case "", "source", "target", "tiempo", "capacidad", "trafico":
+	default:
+		return models.SegmentQueryResult{}, fmt.Errorf("%w: sort must be source, target, tiempo, capacidad or trafico", ErrInvalidSegmentQuery)
+	}
+	if query.GroupBy != "" && query.GroupBy != "source" && query.GroupBy != "target" {
+		return models.SegmentQueryResult{}, fmt.Errorf("%w: group must be source or target", ErrInvalidSegmentQuery)
+	}
+	if query.Offset < 0 || query.Limit < 0 {
+		return models.SegmentQueryResult{}, fmt.Errorf("%w: offset and limit cannot be negative", ErrInvalidSegmentQuery)
+	}
+	if query.MinCapacidad != nil && query.MaxCapacidad != nil && *query.MinCapacidad > *query.MaxCapacidad {
+		return models.SegmentQueryResult{}, fmt.Errorf("%w: min_capacidad is greater than max_capacidad", ErrInvalidSegmentQuery)
+	}
+	if query.MinTiempo != nil && query.MaxTiempo != nil && *query.MinTiempo > *query.MaxTiempo {
+		return models.SegmentQueryResult{}, fmt.Errorf("%w: min_tiempo is greater than max_tiempo", ErrInvalidSegmentQuery)
+	}
+
+	items, total, err := s.RouteRepo.FindSegments(ctx, query)
+	if err != nil {
+		return models.SegmentQueryResult{}, err
+	}
+	result := models.SegmentQueryResult{Items: items, Total: total}
+	if query.GroupBy != "" {
+		if result.Zones, err = s.RouteRepo.AggregateSegments(ctx, query); err != nil {
+			return models.SegmentQueryResult{}, err
+		}
+	}
+	return result, nil
+}
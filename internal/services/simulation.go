@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"neo4j_delivery/internal/dijkstra"
+	"neo4j_delivery/internal/models"
+	"neo4j_delivery/internal/tracing"
+)
+
+var ErrInvalidSimulation = errors.New("invalid simulation")
+
+// Factor aproximado que el tráfico aplica sobre el tiempo de recorrido de un tramo
+var trafficFactors = map[string]float64{
+	"bajo":  1.0,
+	"medio": 1.3,
+	"alto":  1.7,
+}
+
+// Calcula el impacto de cerrar tramos o cambiar su tráfico sin persistir nada en Neo4j.
+// Los tiempos se comparan desde el centro de distribución más cercano a cada zona.
+// Costos y tráfico salen de la misma lectura de los tramos, no de la caché del grafo, para que
+// el factor que se descuenta corresponda al tiempo sobre el que se aplica
+func (s *DeliveryService) SimulateClosures(ctx context.Context, req models.SimulationRequest) (models.SimulationResult, error) {
+	ctx, span := tracing.Start(ctx, "DeliveryService.SimulateClosures")
+	defer span.End()
+	connections, err := s.RouteRepo.GetAllConnections(ctx)
+	if err != nil {
+		return models.SimulationResult{}, err
+	}
+	centers, err := s.centerNames(ctx)
+	if err != nil {
+		return models.SimulationResult{}, err
+	}
+
+	g := make(models.Graph)
+	currentTraffic := make(map[models.SegmentRef]string)
+	for _, connection := range connections {
+		g[connection.Source] = append(g[connection.Source], models.Edge{
+			Item:      connection.Target,
+			Accesible: connection.Accesible,
+			Cost:      float64(connection.Tiempo),
+		})
+		if _, ok := g[connection.Target]; !ok {
+			g[connection.Target] = []models.Edge{}
+		}
+		currentTraffic[models.SegmentRef{Source: connection.Source, Target: connection.Target}] = connection.Trafico
+	}
+
+	scenario := dijkstra.CopyGraph(g)
+	for _, closure := range req.Closures {
+		edge, err := findEdge(scenario, closure.Source, closure.Target)
+		if err != nil {
+			return models.SimulationResult{}, err
+		}
+		edge.Accesible = false
+	}
+	for _, change := range req.Traffic {
+		edge, err := findEdge(scenario, change.Source, change.Target)
+		if err != nil {
+			return models.SimulationResult{}, err
+		}
+		if change.TiempoMinutos != nil {
+			if *change.TiempoMinutos <= 0 {
+				return models.SimulationResult{}, fmt.Errorf("%w: tiempo_minutos must be positive for %s -> %s", ErrInvalidSimulation, change.Source, change.Target)
+			}
+			edge.Cost = *change.TiempoMinutos
+			continue
+		}
+		newFactor, ok := trafficFactors[change.Trafico]
+		if !ok {
+			return models.SimulationResult{}, fmt.Errorf("%w: unknown traffic level %q", ErrInvalidSimulation, change.Trafico)
+		}
+		oldFactor, ok := trafficFactors[currentTraffic[models.SegmentRef{Source: change.Source, Target: change.Target}]]
+		if !ok {
+			oldFactor = 1.0
+		}
+		edge.Cost = edge.Cost * newFactor / oldFactor
+	}
+
+	compute := computeSpan(ctx, "MinCostFromSources")
+	before := dijkstra.MinCostFromSources(dijkstra.AccessibleSubgraph(g), centers)
+	after := dijkstra.MinCostFromSources(dijkstra.AccessibleSubgraph(scenario), centers)
+	compute.End()
+
+	result := models.SimulationResult{Deltas: []models.ZoneDelta{}, NewlyUnreachable: []string{}}
+	for zone, beforeCost := range before {
+		// Las zonas que ya eran inalcanzables no aportan información a la simulación
+		if math.IsInf(beforeCost, 1) {
+			continue
+		}
+		afterCost := after[zone]
+		if math.IsInf(afterCost, 1) {
+			result.NewlyUnreachable = append(result.NewlyUnreachable, zone)
+			result.Deltas = append(result.Deltas, models.ZoneDelta{Zone: zone, BeforeMinutes: beforeCost, Unreachable: true})
+			continue
+		}
+		delta := afterCost - beforeCost
+		// Las mejoras de tráfico dan deltas negativos que no deben compensar las pérdidas de otras zonas
+		if delta > 0 {
+			result.MinutesLost += delta
+		}
+		result.Deltas = append(result.Deltas, models.ZoneDelta{
+			Zone:          zone,
+			BeforeMinutes: beforeCost,
+			AfterMinutes:  afterCost,
+			DeltaMinutes:  delta,
+		})
+	}
+
+	sort.Strings(result.NewlyUnreachable)
+	sort.Slice(result.Deltas, func(i, j int) bool {
+		return result.Deltas[i].Zone < result.Deltas[j].Zone
+	})
+	return result, nil
+}
+
+// Retorna un puntero a la arista source -> target dentro del grafo para poder modificarla
+func findEdge(graph models.Graph, source, target string) (*models.Edge, error) {
+	for i := range graph[source] {
+		if graph[source][i].Item == target {
+			return &graph[source][i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: segment %s -> %s not found", ErrInvalidSimulation, source, target)
+}
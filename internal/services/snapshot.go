@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"neo4j_delivery/internal/dijkstra"
+	"neo4j_delivery/internal/events"
+	"neo4j_delivery/internal/geo"
+	"neo4j_delivery/internal/models"
+	"neo4j_delivery/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var ErrInvalidSnapshotQuery = errors.New("invalid snapshot query")
+
+// Guarda la red actual como una nueva versión
+func (s *DeliveryService) CreateSnapshot(ctx context.Context, description string) (models.SnapshotInfo, error) {
+	ctx, span := tracing.Start(ctx, "DeliveryService.CreateSnapshot")
+	defer span.End()
+	actor, _ := actorFrom(ctx)
+	return s.SnapshotRepo.Capture(ctx, actor, description)
+}
+
+func (s *DeliveryService) ListSnapshots(ctx context.Context) ([]models.SnapshotInfo, error) {
+	ctx, span := tracing.Start(ctx, "DeliveryService.ListSnapshots")
+	defer span.End()
+	return s.SnapshotRepo.List(ctx)
+}
+
+// Snapshot por versión, o el vigente en at si version es 0
+func (s *DeliveryService) GetSnapshot(ctx context.Context, version int, at time.Time) (models.Snapshot, error) {
+	ctx, span := tracing.Start(ctx, "DeliveryService.GetSnapshot")
+	defer span.End()
+	if version > 0 {
+		return s.SnapshotRepo.Find(ctx, version)
+	}
+	return s.SnapshotRepo.FindAt(ctx, at)
+}
+
+// Grafo y coordenadas de un snapshot, con el mismo formato que GetAllAsGraph y FindAll
+func snapshotGraph(snapshot models.Snapshot) (models.Graph, map[string]geo.Point) {
+	g := make(models.Graph, len(snapshot.Zones))
+	coordinates := make(map[string]geo.Point)
+	for _, zone := range snapshot.Zones {
+		g[zone.Nombre] = []models.Edge{}
+		lat, latOk := zone.Props["latitud"].(float64)
+		lng, lngOk := zone.Props["longitud"].(float64)
+		if latOk && lngOk {
+			coordinates[zone.Nombre] = geo.Point{Lat: lat, Lng: lng}
+		}
+	}
+	for _, segment := range snapshot.Segments {
+		cost := 0.0
+		switch tiempo := segment.Props["tiempo_minutos"].(type) {
+		case int64:
+			cost = float64(tiempo)
+		case float64:
+			cost = tiempo
+		}
+		accesible := true
+		if val, ok := segment.Props["accesible"].(bool); ok {
+			accesible = val
+		}
+		g[segment.Source] = append(g[segment.Source], models.Edge{Item: segment.Target, Accesible: accesible, Cost: cost})
+	}
+	return g, coordinates
+}
+
+// Ruta más corta sobre la red tal como estaba en un snapshot
+func (s *DeliveryService) FindShortestPathInSnapshot(ctx context.Context, snapshot models.Snapshot, start, end string, astar bool) ([]string, float64, error) {
+	ctx, span := tracing.Start(ctx, "DeliveryService.FindShortestPathInSnapshot")
+	defer span.End()
+	g, coordinates := snapshotGraph(snapshot)
+	if _, ok := g[start]; !ok {
+		return nil, -1, fmt.Errorf("%w: %s in snapshot %d", ErrZoneNotFound, start, snapshot.Version)
+	}
+	if _, ok := g[end]; !ok {
+		return nil, -1, fmt.Errorf("%w: %s in snapshot %d", ErrZoneNotFound, end, snapshot.Version)
+	}
+
+	if astar {
+		heuristic := dijkstra.TimeHeuristic(g, coordinates, s.MaxSpeedKmh, end)
+		compute := computeSpan(ctx, "AStar", attribute.String("dijkstra.start", start))
+		defer compute.End()
+		return dijkstra.AStar(g, start, end, heuristic)
+	}
+	compute := computeSpan(ctx, "Dijkstra", attribute.String("dijkstra.start", start))
+	table := dijkstra.Dijkstra(g, start)
+	compute.End()
+	return dijkstra.Travel(table, start, end)
+}
+
+// Devuelve la red a la versión indicada. Antes se toma un snapshot de respaldo para poder deshacerlo
+func (s *DeliveryService) RestoreSnapshot(ctx context.Context, version int) (models.RestoreResult, error) {
+	ctx, span := tracing.Start(ctx, "DeliveryService.RestoreSnapshot")
+	defer span.End()
+	snapshot, err := s.SnapshotRepo.Find(ctx, version)
+	if err != nil {
+		return models.RestoreResult{}, err
+	}
+	backup, err := s.CreateSnapshot(ctx, fmt.Sprintf("before restoring version %d", version))
+	if err != nil {
+		return models.RestoreResult{}, err
+	}
+	err = s.SnapshotRepo.Restore(ctx, snapshot)
+	s.invalidateGraph()
+	if err != nil {
+		return models.RestoreResult{}, err
+	}
+
+	s.recordAudit(ctx, models.AuditActionRestoreSnapshot, models.AuditEntityNetwork, []models.PropertyChange{
+		{Before: map[string]int{"version": backup.Version}, After: map[string]int{"version": snapshot.Version}},
+	})
+	zones := make([]string, 0, len(snapshot.Zones))
+	for _, zone := range snapshot.Zones {
+		zones = append(zones, zone.Nombre)
+	}
+	s.Events.Publish(events.NetworkRestored, zones, snapshot.SnapshotInfo)
+
+	return models.RestoreResult{Restored: snapshot.SnapshotInfo, Backup: backup}, nil
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"neo4j_delivery/internal/models"
+)
+
+// Operaciones que el servicio usa de cada repositorio. Los repositorios de Neo4j las cumplen;
+// las pruebas pueden sustituirlas por implementaciones en memoria
+
+type ZoneStore interface {
+	GetGraphData(ctx context.Context) (models.GraphData, error)
+	QueryGraph(ctx context.Context, query models.GraphQuery) (models.GraphData, error)
+	FindAll(ctx context.Context) ([]models.Zone, error)
+	FindOptimalRoute(ctx context.Context, from, to string) ([]models.Connection, error)
+	GetAllAsGraph(ctx context.Context) (models.Graph, error)
+	GetDistributionCenters(ctx context.Context) ([]models.DistributionCenter, error)
+	SaveZoneCentrality(ctx context.Context, scores []models.ZoneCentrality) error
+	UpsertZones(ctx context.Context, zones []models.ImportZone, batchSize int) ([]models.PropertyChange, error)
+	FindZoneRecords(ctx context.Context) ([]models.ZoneRecord, error)
+	CountNetwork(ctx context.Context) (models.NetworkSize, error)
+}
+
+type RouteStore interface {
+	FindSegments(ctx context.Context, query models.SegmentQuery) ([]models.Connection, int, error)
+	AggregateSegments(ctx context.Context, query models.SegmentQuery) ([]models.ZoneSegmentStats, error)
+	GetAllConnections(ctx context.Context) ([]models.Connection, error)
+	SaveSegmentCentrality(ctx context.Context, scores []models.SegmentCentrality) error
+	UpdateSegment(ctx context.Context, source, target string, update models.SegmentUpdate) (models.Connection, models.Connection, error)
+	UpsertConnections(ctx context.Context, connections []models.ImportConnection, batchSize int) ([]models.PropertyChange, error)
+	FindSegmentRecords(ctx context.Context) ([]models.SegmentRecord, error)
+}
+
+type FleetStore interface {
+	FindVehicles(ctx context.Context, center string) ([]models.Vehiculo, error)
+	FindVehicle(ctx context.Context, id string) (models.Vehiculo, error)
+	CreateVehicle(ctx context.Context, vehicle models.Vehiculo) (models.Vehiculo, error)
+	UpdateVehicle(ctx context.Context, vehicle models.Vehiculo) (models.Vehiculo, error)
+	DeleteVehicle(ctx context.Context, id string) error
+	FindDrivers(ctx context.Context, center string) ([]models.Conductor, error)
+	FindDriver(ctx context.Context, id string) (models.Conductor, error)
+	CreateDriver(ctx context.Context, driver models.Conductor) (models.Conductor, error)
+	UpdateDriver(ctx context.Context, driver models.Conductor) (models.Conductor, error)
+	DeleteDriver(ctx context.Context, id string) error
+	SaveTracking(ctx context.Context, tracking models.VehicleTracking, estado string) error
+	FindTracking(ctx context.Context, id string) (models.VehicleTracking, error)
+	FindVehiclesInRoute(ctx context.Context) ([]models.VehicleTracking, error)
+}
+
+type AuditStore interface {
+	Save(ctx context.Context, entries []models.AuditEntry) error
+	FindHistory(ctx context.Context, query models.AuditQuery) ([]models.AuditEntry, error)
+}
+
+type SnapshotStore interface {
+	Capture(ctx context.Context, actor, description string) (models.SnapshotInfo, error)
+	List(ctx context.Context) ([]models.SnapshotInfo, error)
+	Find(ctx context.Context, version int) (models.Snapshot, error)
+	FindAt(ctx context.Context, at time.Time) (models.Snapshot, error)
+	Restore(ctx context.Context, snapshot models.Snapshot) error
+}
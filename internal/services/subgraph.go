@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"neo4j_delivery/internal/dijkstra"
+	"neo4j_delivery/internal/models"
+	"neo4j_delivery/internal/tracing"
+)
+
+var ErrInvalidGraphQuery = errors.New("invalid graph query")
+
+// Retorna el grafo filtrado y paginado. Con Center se limita a las zonas a Hops tramos
+// (cerrados incluidos) y/o a Minutes minutos por tramos accesibles desde esa zona
+func (s *DeliveryService) QueryGraph(ctx context.Context, query models.GraphQuery) (models.GraphData, error) {
+	ctx, span := tracing.Start(ctx, "DeliveryService.QueryGraph")
+	defer span.End()
+	if query.Hops < 0 || query.Minutes < 0 || query.Offset < 0 || query.Limit < 0 {
+		return models.GraphData{}, fmt.Errorf("%w: hops, minutes, offset and limit cannot be negative", ErrInvalidGraphQuery)
+	}
+	if query.Label != "" && query.Label != "Zona" && query.Label != "CentroDistribucion" {
+		return models.GraphData{}, fmt.Errorf("%w: label must be Zona or CentroDistribucion", ErrInvalidGraphQuery)
+	}
+	for _, level := range query.Trafico {
+		if _, ok := trafficFactors[level]; !ok {
+			return models.GraphData{}, fmt.Errorf("%w: unknown traffic level %q", ErrInvalidGraphQuery, level)
+		}
+	}
+	if query.Center == "" {
+		if query.Hops > 0 || query.Minutes > 0 {
+			return models.GraphData{}, fmt.Errorf("%w: hops and minutes need a center zone", ErrInvalidGraphQuery)
+		}
+		return s.ZoneRepo.QueryGraph(ctx, query)
+	}
+
+	g, err := s.networkGraph(ctx)
+	if err != nil {
+		return models.GraphData{}, err
+	}
+	if _, ok := g[query.Center]; !ok {
+		return models.GraphData{}, fmt.Errorf("%w: %s", ErrZoneNotFound, query.Center)
+	}
+
+	// Sin límites explícitos se retorna la zona con sus vecinos directos
+	hops := query.Hops
+	if hops == 0 && query.Minutes == 0 {
+		hops = 1
+	}
+	var inHops map[string]bool
+	if hops > 0 {
+		inHops = dijkstra.WithinHops(g, query.Center, hops)
+	}
+	var costs map[string]float64
+	if query.Minutes > 0 {
+		compute := computeSpan(ctx, "MinCostFromSources")
+		costs = dijkstra.MinCostFromSources(dijkstra.AccessibleSubgraph(g), []string{query.Center})
+		compute.End()
+	}
+
+	query.Names = []string{}
+	for node := range g {
+		if inHops != nil && !inHops[node] {
+			continue
+		}
+		if costs != nil && costs[node] > query.Minutes {
+			continue
+		}
+		query.Names = append(query.Names, node)
+	}
+	return s.ZoneRepo.QueryGraph(ctx, query)
+}
@@ -0,0 +1,231 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"neo4j_delivery/internal/dijkstra"
+	"neo4j_delivery/internal/events"
+	"neo4j_delivery/internal/models"
+	"neo4j_delivery/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Costo de una arista en el grafo actual; ok es false si el tramo no existe o está cerrado
+func segmentCost(graph models.Graph, source, target string) (float64, bool) {
+	for _, edge := range graph[source] {
+		if edge.Item == target && edge.Accesible {
+			return edge.Cost, true
+		}
+	}
+	return 0, false
+}
+
+// Suma el costo de recorrer el camino; ok es false si algún tramo ya no es transitable
+func pathCost(graph models.Graph, path []string) (float64, bool) {
+	total := 0.0
+	for i := 0; i+1 < len(path); i++ {
+		cost, ok := segmentCost(graph, path[i], path[i+1])
+		if !ok {
+			return 0, false
+		}
+		total += cost
+	}
+	return total, true
+}
+
+// Camino y minutos que faltan desde from hasta el destino con el grafo actual. Se sigue la ruta
+// planificada mientras sea transitable; si no, se recalcula y rerouted es true
+func remainingRoute(ctx context.Context, g models.Graph, planned []string, from, destino string) ([]string, float64, bool, error) {
+	path := []string{}
+	if i := slices.Index(planned, from); i >= 0 {
+		path = planned[i:]
+	}
+	if cost, ok := pathCost(g, path); len(path) > 0 && ok {
+		return path, cost, false, nil
+	}
+	compute := computeSpan(ctx, "Dijkstra", attribute.String("dijkstra.start", from))
+	table := dijkstra.Dijkstra(dijkstra.AccessibleSubgraph(g), from)
+	compute.End()
+	path, cost, err := dijkstra.Travel(table, from, destino)
+	return path, cost, true, err
+}
+
+func minutes(value float64) time.Duration {
+	return time.Duration(value * float64(time.Minute))
+}
+
+// Asigna al vehículo la ruta más corta desde su centro hasta el destino y lo marca en ruta
+func (s *DeliveryService) DispatchVehicle(ctx context.Context, req models.DispatchRequest) (models.VehicleTracking, error) {
+	ctx, span := tracing.Start(ctx, "DeliveryService.DispatchVehicle")
+	defer span.End()
+	vehicle, err := s.FleetRepo.FindVehicle(ctx, req.VehiculoID)
+	if err != nil {
+		return models.VehicleTracking{}, err
+	}
+	if vehicle.Estado != models.VehiculoDisponible {
+		return models.VehicleTracking{}, fmt.Errorf("%w: vehicle %q is %s", ErrInvalidFleet, vehicle.ID, vehicle.Estado)
+	}
+
+	g, err := s.networkGraph(ctx)
+	if err != nil {
+		return models.VehicleTracking{}, err
+	}
+	compute := computeSpan(ctx, "Dijkstra", attribute.String("dijkstra.start", vehicle.Centro))
+	table := dijkstra.Dijkstra(dijkstra.AccessibleSubgraph(g), vehicle.Centro)
+	compute.End()
+	path, cost, err := dijkstra.Travel(table, vehicle.Centro, req.Destino)
+	if err != nil {
+		return models.VehicleTracking{}, fmt.Errorf("%w: %v", ErrInvalidFleet, err)
+	}
+
+	now := time.Now()
+	tracking := models.VehicleTracking{
+		VehiculoID:      vehicle.ID,
+		Placa:           vehicle.Placa,
+		Destino:         req.Destino,
+		RutaPlanificada: path,
+		RutaRestante:    path,
+		Zona:            vehicle.Centro,
+		Salida:          now,
+		EtaPlanificada:  now.Add(minutes(cost)),
+		EtaActual:       now.Add(minutes(cost)),
+		ReportadoEn:     now,
+	}
+	if err := s.FleetRepo.SaveTracking(ctx, tracking, models.VehiculoEnRuta); err != nil {
+		return models.VehicleTracking{}, err
+	}
+	s.Events.Publish(events.VehicleMoved, []string{vehicle.Centro}, tracking)
+	return tracking, nil
+}
+
+// Registra la posición del vehículo y recalcula el tiempo restante con el grafo actual.
+// Se sigue la ruta planificada mientras sea transitable; si no, se recalcula desde la posición actual
+func (s *DeliveryService) ReportPosition(ctx context.Context, report models.PositionReport) (models.VehicleTracking, error) {
+	ctx, span := tracing.Start(ctx, "DeliveryService.ReportPosition")
+	defer span.End()
+	tracking, err := s.FleetRepo.FindTracking(ctx, report.VehiculoID)
+	if err != nil {
+		return models.VehicleTracking{}, err
+	}
+	g, err := s.networkGraph(ctx)
+	if err != nil {
+		return models.VehicleTracking{}, err
+	}
+
+	// Tramo en curso: se descuenta lo ya recorrido y se continúa desde su destino
+	from, remaining := report.Zona, 0.0
+	if report.Zona == "" {
+		if report.Source == "" || report.Target == "" || report.Progreso < 0 || report.Progreso > 1 {
+			return models.VehicleTracking{}, fmt.Errorf("%w: report either zona or source, target and progreso between 0 and 1", ErrInvalidFleet)
+		}
+		cost, exists := 0.0, false
+		for _, edge := range g[report.Source] {
+			if edge.Item == report.Target {
+				cost, exists = edge.Cost, true
+				break
+			}
+		}
+		if !exists {
+			return models.VehicleTracking{}, fmt.Errorf("%w: segment %s -> %s does not exist", ErrInvalidFleet, report.Source, report.Target)
+		}
+		from, remaining = report.Target, (1-report.Progreso)*cost
+	}
+
+	path, cost, rerouted, err := remainingRoute(ctx, g, tracking.RutaPlanificada, from, tracking.Destino)
+	if err != nil {
+		return models.VehicleTracking{}, fmt.Errorf("%w: %v", ErrInvalidFleet, err)
+	}
+	tracking.Desviado = tracking.Desviado || rerouted
+
+	now := time.Now()
+	tracking.Zona, tracking.Source, tracking.Target, tracking.Progreso = report.Zona, report.Source, report.Target, report.Progreso
+	tracking.RutaRestante = path
+	tracking.EtaActual = now.Add(minutes(remaining + cost))
+	tracking.RetrasoMinutos = tracking.EtaActual.Sub(tracking.EtaPlanificada).Minutes()
+	tracking.ReportadoEn = now
+
+	// Al llegar al destino el vehículo vuelve a quedar disponible
+	estado := ""
+	if report.Zona == tracking.Destino {
+		estado = models.VehiculoDisponible
+	}
+	if err := s.FleetRepo.SaveTracking(ctx, tracking, estado); err != nil {
+		return models.VehicleTracking{}, err
+	}
+	zones := []string{report.Zona}
+	if report.Zona == "" {
+		zones = []string{report.Source, report.Target}
+	}
+	s.Events.Publish(events.VehicleMoved, zones, tracking)
+	return tracking, nil
+}
+
+func (s *DeliveryService) GetTracking(ctx context.Context, id string) (models.VehicleTracking, error) {
+	ctx, span := tracing.Start(ctx, "DeliveryService.GetTracking")
+	defer span.End()
+	return s.FleetRepo.FindTracking(ctx, id)
+}
+
+// Vehículos en ruta cuyo retraso supera el umbral. El retraso se recalcula con el grafo y la hora
+// actuales: un cierre o un cambio de tráfico posterior al último reporte, o un vehículo que dejó de
+// reportar, también cuentan
+func (s *DeliveryService) FindDelayedVehicles(ctx context.Context, thresholdMinutes float64) ([]models.VehicleTracking, error) {
+	ctx, span := tracing.Start(ctx, "DeliveryService.FindDelayedVehicles")
+	defer span.End()
+	vehicles, err := s.FleetRepo.FindVehiclesInRoute(ctx)
+	if err != nil {
+		return nil, err
+	}
+	g, err := s.networkGraph(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	delayed := []models.VehicleTracking{}
+	for _, tracking := range vehicles {
+		tracking = projectTracking(ctx, g, tracking, now)
+		if tracking.RetrasoMinutos > thresholdMinutes {
+			delayed = append(delayed, tracking)
+		}
+	}
+	sort.Slice(delayed, func(i, j int) bool { return delayed[i].RetrasoMinutos > delayed[j].RetrasoMinutos })
+	return delayed, nil
+}
+
+// Estima la llegada a partir de la última posición reportada: lo que faltaba recorrer con el grafo
+// actual, contado desde el reporte. Si esa hora ya pasó sin que el vehículo informara la llegada, la
+// llegada es como pronto ahora. Sin camino al destino se conserva la última estimación
+func projectTracking(ctx context.Context, g models.Graph, tracking models.VehicleTracking, now time.Time) models.VehicleTracking {
+	from, remaining := tracking.Zona, 0.0
+	if from == "" {
+		from = tracking.Target
+		for _, edge := range g[tracking.Source] {
+			if edge.Item == tracking.Target {
+				remaining = (1 - tracking.Progreso) * edge.Cost
+				break
+			}
+		}
+	}
+
+	eta := tracking.EtaActual
+	path, cost, rerouted, err := remainingRoute(ctx, g, tracking.RutaRestante, from, tracking.Destino)
+	if err != nil {
+		path, rerouted = []string{}, true
+	} else {
+		eta = tracking.ReportadoEn.Add(minutes(remaining + cost))
+	}
+	if eta.Before(now) {
+		eta = now
+	}
+	tracking.RutaRestante = path
+	tracking.Desviado = tracking.Desviado || rerouted
+	tracking.EtaActual = eta
+	tracking.RetrasoMinutos = eta.Sub(tracking.EtaPlanificada).Minutes()
+	return tracking
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"neo4j_delivery/internal/models"
+	"neo4j_delivery/internal/tracing"
+	"neo4j_delivery/internal/validation"
+)
+
+func (s *DeliveryService) networkRecords(ctx context.Context) ([]models.ZoneRecord, []models.SegmentRecord, error) {
+	zones, err := s.ZoneRepo.FindZoneRecords(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	segments, err := s.RouteRepo.FindSegmentRecords(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return zones, segments, nil
+}
+
+// Revisa la integridad de la red guardada
+func (s *DeliveryService) ValidateNetwork(ctx context.Context) (models.ValidationReport, error) {
+	ctx, span := tracing.Start(ctx, "DeliveryService.ValidateNetwork")
+	defer span.End()
+	zones, segments, err := s.networkRecords(ctx)
+	if err != nil {
+		return models.ValidationReport{}, err
+	}
+	return validation.Check(zones, segments), nil
+}
+
+// Aplica la importación sobre copias de los registros, igual que lo harían UpsertZones y UpsertConnections
+func mergeImport(zones []models.ZoneRecord, segments []models.SegmentRecord, data models.ImportData) ([]models.ZoneRecord, []models.SegmentRecord) {
+	mergedZones := append([]models.ZoneRecord{}, zones...)
+	zoneIndex := make(map[string]int, len(zones))
+	for i, zone := range mergedZones {
+		zoneIndex[zone.Nombre] = i
+	}
+	for _, zone := range data.Zones {
+		if i, ok := zoneIndex[zone.Nombre]; ok {
+			mergedZones[i].Centro = mergedZones[i].Centro || zone.CentroDistribucion
+			if zone.TipoZona != "" {
+				mergedZones[i].Missing = withoutProperty(mergedZones[i].Missing, "tipo_zona")
+			}
+			continue
+		}
+		record := models.ZoneRecord{Nombre: zone.Nombre, Centro: zone.CentroDistribucion}
+		if zone.TipoZona == "" {
+			record.Missing = []string{"tipo_zona"}
+		}
+		zoneIndex[zone.Nombre] = len(mergedZones)
+		mergedZones = append(mergedZones, record)
+	}
+
+	mergedSegments := append([]models.SegmentRecord{}, segments...)
+	segmentIndex := make(map[[2]string]int, len(segments))
+	for i, segment := range mergedSegments {
+		segmentIndex[[2]string{segment.Source, segment.Target}] = i
+	}
+	for _, connection := range data.Connections {
+		key := [2]string{connection.Source, connection.Target}
+		i, ok := segmentIndex[key]
+		if !ok {
+			i = len(mergedSegments)
+			segmentIndex[key] = i
+			mergedSegments = append(mergedSegments, models.SegmentRecord{Source: connection.Source, Target: connection.Target, Accesible: true})
+		}
+		mergedSegments[i].TiempoMinutos = float64(connection.TiempoMinutos)
+		if connection.Accesible != nil {
+			mergedSegments[i].Accesible = *connection.Accesible
+		}
+	}
+	return mergedZones, mergedSegments
+}
+
+// Copia de missing sin la propiedad indicada, para no modificar el registro original
+func withoutProperty(missing []string, property string) []string {
+	var rest []string
+	for _, name := range missing {
+		if name != property {
+			rest = append(rest, name)
+		}
+	}
+	return rest
+}
@@ -0,0 +1,174 @@
+package validation
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"neo4j_delivery/internal/dijkstra"
+	"neo4j_delivery/internal/models"
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+
+	CategoryMissingProperty = "missing_property"
+	CategorySelfLoop        = "self_loop"
+	CategoryInvalidTime     = "invalid_time"
+	CategoryMismatchedPair  = "mismatched_pair"
+	CategoryIsolatedZone    = "isolated_zone"
+	CategoryUnreachableZone = "unreachable_zone"
+	CategoryNoCenters       = "no_centers"
+)
+
+// Severidad de cada categoría. Los errores rompen consultas; las advertencias son inconsistencias
+// que el grafo tolera
+var severities = map[string]string{
+	CategoryMissingProperty: SeverityError,
+	CategorySelfLoop:        SeverityError,
+	CategoryInvalidTime:     SeverityError,
+	CategoryMismatchedPair:  SeverityWarning,
+	CategoryIsolatedZone:    SeverityWarning,
+	CategoryUnreachableZone: SeverityWarning,
+	CategoryNoCenters:       SeverityWarning,
+}
+
+// Check revisa zonas y tramos y retorna el reporte agrupado por categoría.
+// La alcanzabilidad desde los centros solo considera tramos accesibles
+func Check(zones []models.ZoneRecord, segments []models.SegmentRecord) models.ValidationReport {
+	issues := []models.ValidationIssue{}
+	add := func(category string, zones []string, format string, args ...interface{}) {
+		issues = append(issues, models.ValidationIssue{
+			Category: category,
+			Severity: severities[category],
+			Zones:    zones,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	graph := make(models.Graph, len(zones))
+	centers := []string{}
+	for _, zone := range zones {
+		if len(zone.Missing) > 0 {
+			name := zone.Nombre
+			if name == "" {
+				name = fmt.Sprintf("#%d", zone.ID)
+			}
+			add(CategoryMissingProperty, nonEmpty(zone.Nombre), "zone %s is missing %s", name, strings.Join(zone.Missing, ", "))
+		}
+		if zone.Nombre == "" {
+			continue
+		}
+		graph[zone.Nombre] = []models.Edge{}
+		if zone.Centro {
+			centers = append(centers, zone.Nombre)
+		}
+	}
+
+	connected := make(map[string]bool)
+	times := make(map[[2]string]float64)
+	for _, segment := range segments {
+		label := fmt.Sprintf("%s -> %s", segment.Source, segment.Target)
+		endpoints := nonEmpty(segment.Source, segment.Target)
+		if len(segment.Missing) > 0 {
+			add(CategoryMissingProperty, endpoints, "segment #%d %s is missing %s", segment.ID, label, strings.Join(segment.Missing, ", "))
+		}
+		if segment.Source == "" || segment.Target == "" {
+			continue
+		}
+		if segment.Source == segment.Target {
+			add(CategorySelfLoop, endpoints, "segment #%d loops on %s", segment.ID, segment.Source)
+		}
+		timeMissing := slices.Contains(segment.Missing, "tiempo_minutos") && segment.TiempoMinutos == 0
+		if !timeMissing && segment.TiempoMinutos <= 0 {
+			add(CategoryInvalidTime, endpoints, "segment #%d %s has tiempo_minutos %v", segment.ID, label, segment.TiempoMinutos)
+		}
+
+		connected[segment.Source] = true
+		connected[segment.Target] = true
+		times[[2]string{segment.Source, segment.Target}] = segment.TiempoMinutos
+		graph[segment.Source] = append(graph[segment.Source], models.Edge{Item: segment.Target, Accesible: segment.Accesible, Cost: segment.TiempoMinutos})
+	}
+
+	for pair, forward := range times {
+		if pair[0] >= pair[1] {
+			continue
+		}
+		backward, ok := times[[2]string{pair[1], pair[0]}]
+		if ok && backward != forward {
+			add(CategoryMismatchedPair, []string{pair[0], pair[1]}, "%s -> %s takes %v min but %s -> %s takes %v min",
+				pair[0], pair[1], forward, pair[1], pair[0], backward)
+		}
+	}
+
+	names := make([]string, 0, len(graph))
+	for name := range graph {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if len(centers) == 0 {
+		add(CategoryNoCenters, nil, "there are no distribution centers")
+	}
+	reachable := dijkstra.ReachableFrom(graph, centers)
+	for _, name := range names {
+		if !connected[name] {
+			add(CategoryIsolatedZone, []string{name}, "zone %s has no segments", name)
+		} else if len(centers) > 0 && !reachable[name] {
+			add(CategoryUnreachableZone, []string{name}, "zone %s cannot be reached from any distribution center", name)
+		}
+	}
+
+	return newReport(issues)
+}
+
+func nonEmpty(values ...string) []string {
+	result := []string{}
+	for _, value := range values {
+		if value != "" && !slices.Contains(result, value) {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+func newReport(issues []models.ValidationIssue) models.ValidationReport {
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Severity != issues[j].Severity {
+			return issues[i].Severity == SeverityError
+		}
+		if issues[i].Category != issues[j].Category {
+			return issues[i].Category < issues[j].Category
+		}
+		return issues[i].Message < issues[j].Message
+	})
+
+	report := models.ValidationReport{Categories: make(map[string]int), Issues: issues}
+	for _, issue := range issues {
+		report.Categories[issue.Category]++
+		if issue.Severity == SeverityError {
+			report.Errors++
+		} else {
+			report.Warnings++
+		}
+	}
+	report.Valid = report.Errors == 0
+	return report
+}
+
+// NewIssues retorna las incidencias de after que no estaban en before
+func NewIssues(before, after models.ValidationReport) []models.ValidationIssue {
+	seen := make(map[string]bool, len(before.Issues))
+	for _, issue := range before.Issues {
+		seen[issue.Category+"\x00"+issue.Message] = true
+	}
+	added := []models.ValidationIssue{}
+	for _, issue := range after.Issues {
+		if !seen[issue.Category+"\x00"+issue.Message] {
+			added = append(added, issue)
+		}
+	}
+	return added
+}
@@ -1,20 +1,22 @@
-// Limpieza inicial (opcional)
-MATCH (n) DETACH DELETE n;
+// Red inicial. El servidor solo ejecuta este script si la base no tiene zonas
 
 // Creación de nodos
-CREATE (cd1:CentroDistribucion:Zona {nombre: 'Centro Principal', tipo_zona: 'logistica', capacidad_vehiculos: 50});
-CREATE (cd2:CentroDistribucion:Zona {nombre: 'Centro Secundario', tipo_zona: 'logistica', capacidad_vehiculos: 30});
-
-CREATE (z1:Zona {nombre: 'AltaVista', tipo_zona: 'comercial'});
-CREATE (z2:Zona {nombre: 'Castillito', tipo_zona: 'residencial'});
-CREATE (z3:Zona {nombre: 'Puerto Ordaz', tipo_zona: 'mixto'});
-CREATE (z4:Zona {nombre: 'Villa Asia', tipo_zona: 'residencial'});
-CREATE (z5:Zona {nombre: 'Los Olivos', tipo_zona: 'residencial'});
-CREATE (z6:Zona {nombre: 'San Félix', tipo_zona: 'mixto'});
-CREATE (z7:Zona {nombre: 'Unare', tipo_zona: 'comercial'});
-CREATE (z8:Zona {nombre: 'Cauca', tipo_zona: 'residencial'});
-CREATE (z9:Zona {nombre: 'Las Palmas', tipo_zona: 'residencial'});
-CREATE (z10:Zona {nombre: 'Paseo Caroni', tipo_zona: 'comercial'});
+// latitud/longitud son el centroide de la zona (WGS84)
+// limite es el polígono de la zona como lista plana [lng1, lat1, lng2, lat2, ...]
+// Las zonas residenciales solo reciben entregas en su ventana horaria (ventana_inicio - ventana_fin)
+CREATE (cd1:CentroDistribucion:Zona {nombre: 'Centro Principal', tipo_zona: 'logistica', capacidad_vehiculos: 50, latitud: 8.287, longitud: -62.748, limite: [-62.752, 8.283, -62.744, 8.283, -62.744, 8.291, -62.752, 8.291]});
+CREATE (cd2:CentroDistribucion:Zona {nombre: 'Centro Secundario', tipo_zona: 'logistica', capacidad_vehiculos: 30, latitud: 8.355, longitud: -62.66, limite: [-62.664, 8.351, -62.656, 8.351, -62.656, 8.359, -62.664, 8.359]});
+
+CREATE (z1:Zona {nombre: 'AltaVista', tipo_zona: 'comercial', latitud: 8.293, longitud: -62.735, limite: [-62.739, 8.289, -62.731, 8.289, -62.731, 8.297, -62.739, 8.297]});
+CREATE (z2:Zona {nombre: 'Castillito', tipo_zona: 'residencial', ventana_inicio: '09:00', ventana_fin: '17:00', tiempo_servicio: 10, latitud: 8.305, longitud: -62.715, limite: [-62.719, 8.301, -62.711, 8.301, -62.711, 8.309, -62.719, 8.309]});
+CREATE (z3:Zona {nombre: 'Puerto Ordaz', tipo_zona: 'mixto', latitud: 8.296, longitud: -62.723, limite: [-62.727, 8.292, -62.719, 8.292, -62.719, 8.3, -62.727, 8.3]});
+CREATE (z4:Zona {nombre: 'Villa Asia', tipo_zona: 'residencial', ventana_inicio: '09:00', ventana_fin: '17:00', tiempo_servicio: 10, latitud: 8.312, longitud: -62.705, limite: [-62.709, 8.308, -62.701, 8.308, -62.701, 8.316, -62.709, 8.316]});
+CREATE (z5:Zona {nombre: 'Los Olivos', tipo_zona: 'residencial', ventana_inicio: '09:00', ventana_fin: '17:00', tiempo_servicio: 10, latitud: 8.3, longitud: -62.7, limite: [-62.704, 8.296, -62.696, 8.296, -62.696, 8.304, -62.704, 8.304]});
+CREATE (z6:Zona {nombre: 'San Félix', tipo_zona: 'mixto', latitud: 8.36, longitud: -62.645, limite: [-62.649, 8.356, -62.641, 8.356, -62.641, 8.364, -62.649, 8.364]});
+CREATE (z7:Zona {nombre: 'Unare', tipo_zona: 'comercial', latitud: 8.278, longitud: -62.77, limite: [-62.774, 8.274, -62.766, 8.274, -62.766, 8.282, -62.774, 8.282]});
+CREATE (z8:Zona {nombre: 'Cauca', tipo_zona: 'residencial', ventana_inicio: '09:00', ventana_fin: '17:00', tiempo_servicio: 10, latitud: 8.32, longitud: -62.69, limite: [-62.694, 8.316, -62.686, 8.316, -62.686, 8.324, -62.694, 8.324]});
+CREATE (z9:Zona {nombre: 'Las Palmas', tipo_zona: 'residencial', ventana_inicio: '09:00', ventana_fin: '17:00', tiempo_servicio: 10, latitud: 8.285, longitud: -62.71, limite: [-62.714, 8.281, -62.706, 8.281, -62.706, 8.289, -62.714, 8.289]});
+CREATE (z10:Zona {nombre: 'Paseo Caroni', tipo_zona: 'comercial', latitud: 8.287, longitud: -62.76, limite: [-62.764, 8.283, -62.756, 8.283, -62.756, 8.291, -62.764, 8.291]});
 
 // Conexión desde Centro Principal
 MATCH (cd1:CentroDistribucion {nombre: 'Centro Principal'})
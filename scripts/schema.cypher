@@ -0,0 +1,5 @@
+// Restricciones del esquema. El servidor ejecuta este script en cada arranque; IF NOT EXISTS
+// lo hace idempotente, a diferencia de data.cypher, que solo se carga en una base vacía
+
+// Dos snapshots tomados a la vez no pueden quedar con la misma versión
+CREATE CONSTRAINT snapshot_version IF NOT EXISTS FOR (s:Snapshot) REQUIRE s.version IS UNIQUE;